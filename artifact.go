@@ -0,0 +1,458 @@
+package go_eth_client
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/meshplus/go-eth-client/compiler"
+)
+
+// Artifact is a compiled contract ready to deploy, the bytecodes keep the placeholders of
+// unlinked libraries
+type Artifact struct {
+	ContractName           string                                  // 合约名
+	SourceName             string                                  // 合约所在的源文件，未知时为空
+	Abi                    json.RawMessage                         // 合约ABI
+	Bytecode               string                                  // 部署用的字节码，0x开头
+	DeployedBytecode       string                                  // 链上的运行时字节码，0x开头
+	LinkReferences         map[string]map[string][]compiler.Offset // Bytecode中库地址占位符的位置，源文件 -> 库名 -> 位置
+	DeployedLinkReferences map[string]map[string][]compiler.Offset // DeployedBytecode中库地址占位符的位置
+	ImmutableReferences    map[string][]compiler.Offset            // DeployedBytecode中immutable变量的位置，AST id -> 位置
+	Kind                   string                                  // 合约类型：contract、library或interface，未知时为空
+	Abstract               bool                                    // 是否为抽象合约
+}
+
+// Id returns the fully qualified name of the contract, file.sol:Contract, or the contract name if the source is unknown
+func (a *Artifact) Id() string {
+	if a.SourceName == "" {
+		return a.ContractName
+	}
+	return a.SourceName + ":" + a.ContractName
+}
+
+// Deployable reports whether the artifact can be deployed, interfaces and abstract contracts can't.
+// Artifacts without ast, such as those of Hardhat, are told by having creation bytecode.
+func (a *Artifact) Deployable() bool {
+	return a.Kind != compiler.KindInterface && !a.Abstract && strings.TrimPrefix(a.Bytecode, "0x") != ""
+}
+
+// NewArtifact converts a contract compiled by compiler to an artifact
+func NewArtifact(contract *compiler.Contract) *Artifact {
+	return &Artifact{
+		ContractName:           contract.Name,
+		SourceName:             contract.File,
+		Abi:                    contract.Abi,
+		Bytecode:               hexPrefixed(contract.Bytecode.Object),
+		DeployedBytecode:       hexPrefixed(contract.DeployedBytecode.Object),
+		LinkReferences:         contract.Bytecode.LinkReferences,
+		DeployedLinkReferences: contract.DeployedBytecode.LinkReferences,
+		ImmutableReferences:    contract.DeployedBytecode.ImmutableReferences,
+		Kind:                   contract.Kind,
+		Abstract:               contract.Abstract,
+	}
+}
+
+// hardhatArtifact is the artifact format of Hardhat, artifacts/<source>/<Contract>.json
+type hardhatArtifact struct {
+	Format                 string                                  `json:"_format"`
+	ContractName           string                                  `json:"contractName"`
+	SourceName             string                                  `json:"sourceName"`
+	Abi                    json.RawMessage                         `json:"abi"`
+	Bytecode               string                                  `json:"bytecode"`
+	DeployedBytecode       string                                  `json:"deployedBytecode"`
+	LinkReferences         map[string]map[string][]compiler.Offset `json:"linkReferences"`
+	DeployedLinkReferences map[string]map[string][]compiler.Offset `json:"deployedLinkReferences"`
+}
+
+// hardhatBuildInfo is the part of the solc output in build-info/<id>.json which holds the immutable references
+type hardhatBuildInfo struct {
+	Output struct {
+		Contracts map[string]map[string]struct {
+			Evm struct {
+				DeployedBytecode compiler.Bytecode `json:"deployedBytecode"`
+			} `json:"evm"`
+		} `json:"contracts"`
+	} `json:"output"`
+}
+
+// foundryArtifact is the artifact format of Foundry, out/<File>.sol/<Contract>.json
+type foundryArtifact struct {
+	Abi              json.RawMessage     `json:"abi"`
+	Bytecode         compiler.Bytecode   `json:"bytecode"`
+	DeployedBytecode compiler.Bytecode   `json:"deployedBytecode"`
+	Metadata         json.RawMessage     `json:"metadata"` // an object, or a string in older versions
+	Ast              compiler.SourceUnit `json:"ast"`
+}
+
+// truffleArtifact is the artifact format of Truffle, build/contracts/<Contract>.json
+type truffleArtifact struct {
+	ContractName        string                       `json:"contractName"`
+	Abi                 json.RawMessage              `json:"abi"`
+	Bytecode            string                       `json:"bytecode"`
+	DeployedBytecode    string                       `json:"deployedBytecode"`
+	SourcePath          string                       `json:"sourcePath"`
+	ImmutableReferences map[string][]compiler.Offset `json:"immutableReferences"`
+	Ast                 compiler.SourceUnit          `json:"ast"`
+}
+
+// LoadHardhatArtifact loads artifacts/<source>/<Contract>.json of Hardhat, the immutable references
+// are read from the build info the Contract.dbg.json next to it points to, if there is one
+func LoadHardhatArtifact(path string) (*Artifact, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read hardhat artifact: %w", err)
+	}
+	var hh hardhatArtifact
+	if err := json.Unmarshal(data, &hh); err != nil {
+		return nil, fmt.Errorf("decode hardhat artifact %s: %w", path, err)
+	}
+	if !strings.HasPrefix(hh.Format, "hh-sol-artifact") {
+		return nil, fmt.Errorf("%s is not a hardhat artifact", path)
+	}
+	artifact := &Artifact{
+		ContractName:           hh.ContractName,
+		SourceName:             hh.SourceName,
+		Abi:                    hh.Abi,
+		Bytecode:               hexPrefixed(hh.Bytecode),
+		DeployedBytecode:       hexPrefixed(hh.DeployedBytecode),
+		LinkReferences:         hh.LinkReferences,
+		DeployedLinkReferences: hh.DeployedLinkReferences,
+	}
+
+	dbgPath := strings.TrimSuffix(path, ".json") + ".dbg.json"
+	dbgData, err := ioutil.ReadFile(dbgPath)
+	if os.IsNotExist(err) {
+		return artifact, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read hardhat debug file: %w", err)
+	}
+	var dbg struct {
+		BuildInfo string `json:"buildInfo"`
+	}
+	if err := json.Unmarshal(dbgData, &dbg); err != nil {
+		return nil, fmt.Errorf("decode hardhat debug file %s: %w", dbgPath, err)
+	}
+	if dbg.BuildInfo == "" {
+		return artifact, nil
+	}
+	buildInfoData, err := ioutil.ReadFile(filepath.Join(filepath.Dir(path), filepath.FromSlash(dbg.BuildInfo)))
+	if err != nil {
+		return nil, fmt.Errorf("read hardhat build info: %w", err)
+	}
+	var buildInfo hardhatBuildInfo
+	if err := json.Unmarshal(buildInfoData, &buildInfo); err != nil {
+		return nil, fmt.Errorf("decode hardhat build info %s: %w", dbg.BuildInfo, err)
+	}
+	contract := buildInfo.Output.Contracts[hh.SourceName][hh.ContractName]
+	artifact.ImmutableReferences = contract.Evm.DeployedBytecode.ImmutableReferences
+	return artifact, nil
+}
+
+// LoadFoundryArtifact loads out/<File>.sol/<Contract>.json of Foundry
+func LoadFoundryArtifact(path string) (*Artifact, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read foundry artifact: %w", err)
+	}
+	var forge foundryArtifact
+	if err := json.Unmarshal(data, &forge); err != nil {
+		return nil, fmt.Errorf("decode foundry artifact %s: %w", path, err)
+	}
+	artifact := &Artifact{
+		ContractName:           strings.TrimSuffix(filepath.Base(path), ".json"),
+		SourceName:             forge.Ast.AbsolutePath,
+		Abi:                    forge.Abi,
+		Bytecode:               hexPrefixed(forge.Bytecode.Object),
+		DeployedBytecode:       hexPrefixed(forge.DeployedBytecode.Object),
+		LinkReferences:         forge.Bytecode.LinkReferences,
+		DeployedLinkReferences: forge.DeployedBytecode.LinkReferences,
+		ImmutableReferences:    forge.DeployedBytecode.ImmutableReferences,
+	}
+	var metadata struct {
+		Settings struct {
+			CompilationTarget map[string]string `json:"compilationTarget"`
+		} `json:"settings"`
+	}
+	if len(forge.Metadata) != 0 && forge.Metadata[0] == '"' {
+		var raw string
+		if err := json.Unmarshal(forge.Metadata, &raw); err == nil {
+			forge.Metadata = json.RawMessage(raw)
+		}
+	}
+	if err := json.Unmarshal(forge.Metadata, &metadata); err == nil {
+		for source, name := range metadata.Settings.CompilationTarget {
+			artifact.SourceName, artifact.ContractName = source, name
+		}
+	}
+	if definition, ok := forge.Ast.Contract(artifact.ContractName); ok {
+		artifact.Kind, artifact.Abstract = definition.ContractKind, definition.Abstract
+	}
+	return artifact, nil
+}
+
+// LoadTruffleArtifact loads build/contracts/<Contract>.json of Truffle, which holds no link references,
+// they are found from the placeholders in the bytecodes
+func LoadTruffleArtifact(path string) (*Artifact, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read truffle artifact: %w", err)
+	}
+	var truffle truffleArtifact
+	if err := json.Unmarshal(data, &truffle); err != nil {
+		return nil, fmt.Errorf("decode truffle artifact %s: %w", path, err)
+	}
+	sourceName := strings.TrimPrefix(truffle.Ast.AbsolutePath, "project:/")
+	if sourceName == "" {
+		sourceName = truffle.SourcePath
+	}
+	artifact := &Artifact{
+		ContractName:        truffle.ContractName,
+		SourceName:          sourceName,
+		Abi:                 truffle.Abi,
+		Bytecode:            hexPrefixed(truffle.Bytecode),
+		DeployedBytecode:    hexPrefixed(truffle.DeployedBytecode),
+		ImmutableReferences: truffle.ImmutableReferences,
+	}
+	artifact.LinkReferences = findLinkReferences(artifact.Bytecode)
+	artifact.DeployedLinkReferences = findLinkReferences(artifact.DeployedBytecode)
+	if definition, ok := truffle.Ast.Contract(artifact.ContractName); ok {
+		artifact.Kind, artifact.Abstract = definition.ContractKind, definition.Abstract
+	}
+	return artifact, nil
+}
+
+// LoadAbiBin loads a contract from a .abi file and a .bin file holding the hex bytecode,
+// the contract is named after the .abi file
+func LoadAbiBin(abiPath, binPath string) (*Artifact, error) {
+	abiData, err := ioutil.ReadFile(abiPath)
+	if err != nil {
+		return nil, fmt.Errorf("read abi: %w", err)
+	}
+	if !json.Valid(abiData) {
+		return nil, fmt.Errorf("%s is not a json abi", abiPath)
+	}
+	binData, err := ioutil.ReadFile(binPath)
+	if err != nil {
+		return nil, fmt.Errorf("read bin: %w", err)
+	}
+	artifact := &Artifact{
+		ContractName: strings.TrimSuffix(filepath.Base(abiPath), filepath.Ext(abiPath)),
+		Abi:          abiData,
+		Bytecode:     hexPrefixed(strings.TrimSpace(string(binData))),
+	}
+	artifact.LinkReferences = findLinkReferences(artifact.Bytecode)
+	return artifact, nil
+}
+
+// errNotArtifact is returned by LoadArtifact for json files without abi and bytecode
+var errNotArtifact = errors.New("not an artifact")
+
+// LoadArtifact loads a Hardhat, Foundry or Truffle artifact, telling the format from its fields
+func LoadArtifact(path string) (*Artifact, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read artifact: %w", err)
+	}
+	var probe struct {
+		Format       string          `json:"_format"`
+		ContractName string          `json:"contractName"`
+		Abi          json.RawMessage `json:"abi"`
+		Bytecode     json.RawMessage `json:"bytecode"`
+	}
+	if err := json.Unmarshal(data, &probe); err != nil {
+		var typeErr *json.UnmarshalTypeError
+		if errors.As(err, &typeErr) {
+			return nil, fmt.Errorf("%s is %w", path, errNotArtifact)
+		}
+		return nil, fmt.Errorf("decode artifact %s: %w", path, err)
+	}
+	switch {
+	case strings.HasPrefix(probe.Format, "hh-sol-artifact"):
+		return LoadHardhatArtifact(path)
+	case len(probe.Abi) == 0 || len(probe.Bytecode) == 0:
+		return nil, fmt.Errorf("%s is %w", path, errNotArtifact)
+	case probe.Bytecode[0] == '{':
+		return LoadFoundryArtifact(path)
+	case probe.ContractName != "":
+		return LoadTruffleArtifact(path)
+	default:
+		return nil, fmt.Errorf("unknown artifact format of %s", path)
+	}
+}
+
+// LoadArtifacts loads every artifact under dir, such as the artifacts directory of Hardhat,
+// the out directory of Foundry, the build/contracts directory of Truffle or a directory of
+// .abi and .bin pairs. Hardhat debug files, build infos and json files without abi and bytecode
+// are skipped, a json file which fails to load otherwise is an error.
+func LoadArtifacts(dir string) ([]*Artifact, error) {
+	var artifacts []*Artifact
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if d.Name() == "build-info" {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		switch {
+		case strings.HasSuffix(path, ".dbg.json"):
+		case filepath.Ext(path) == ".json":
+			artifact, err := LoadArtifact(path)
+			if errors.Is(err, errNotArtifact) {
+				// other json files, such as caches, live next to the artifacts
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+			artifacts = append(artifacts, artifact)
+		case filepath.Ext(path) == ".abi":
+			binPath := strings.TrimSuffix(path, ".abi") + ".bin"
+			if _, err := os.Stat(binPath); err != nil {
+				return nil
+			}
+			artifact, err := LoadAbiBin(path, binPath)
+			if err != nil {
+				return err
+			}
+			artifacts = append(artifacts, artifact)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("load artifacts: %w", err)
+	}
+	sort.Slice(artifacts, func(i, j int) bool {
+		return artifacts[i].Id() < artifacts[j].Id()
+	})
+	return artifacts, nil
+}
+
+// NewCompileResult puts artifacts into a CompileResult which Deploy accepts, ordered by their names
+func NewCompileResult(artifacts ...*Artifact) *CompileResult {
+	result := &CompileResult{Artifacts: NewArtifacts(artifacts...)}
+	for _, id := range result.Artifacts.Ids() {
+		artifact := result.Artifacts[id]
+		result.Abi = append(result.Abi, string(artifact.Abi))
+		result.Bin = append(result.Bin, artifact.Bytecode)
+		result.Names = append(result.Names, id)
+	}
+	return result
+}
+
+// artifacts returns the indexed artifacts of result, they are made from Abi, Bin and Names
+// if result is filled by hand
+func (result *CompileResult) artifacts() Artifacts {
+	if result.Artifacts != nil {
+		return result.Artifacts
+	}
+	artifacts := make(Artifacts, len(result.Names))
+	for i, name := range result.Names {
+		artifact := &Artifact{ContractName: name}
+		if j := strings.LastIndex(name, ":"); j >= 0 {
+			artifact.SourceName, artifact.ContractName = name[:j], name[j+1:]
+		}
+		if i < len(result.Abi) {
+			artifact.Abi = json.RawMessage(result.Abi[i])
+		}
+		if i < len(result.Bin) {
+			artifact.Bytecode = hexPrefixed(strings.TrimSpace(result.Bin[i]))
+			artifact.LinkReferences = findLinkReferences(artifact.Bytecode)
+		}
+		artifacts[name] = artifact
+	}
+	return artifacts
+}
+
+// deployable reports whether the i-th contract of result can be deployed
+func (result *CompileResult) deployable(i int) bool {
+	if i < len(result.Names) {
+		if artifact, ok := result.Artifacts[result.Names[i]]; ok {
+			return artifact.Deployable()
+		}
+	}
+	return strings.TrimPrefix(strings.TrimSpace(result.Bin[i]), "0x") != ""
+}
+
+// Artifacts indexes artifacts by the fully qualified names of the contracts, file.sol:Contract
+type Artifacts map[string]*Artifact
+
+// NewArtifacts indexes artifacts, a later artifact replaces an earlier one with the same name
+func NewArtifacts(artifacts ...*Artifact) Artifacts {
+	indexed := make(Artifacts, len(artifacts))
+	for _, artifact := range artifacts {
+		indexed[artifact.Id()] = artifact
+	}
+	return indexed
+}
+
+// Ids returns the sorted names of the artifacts
+func (a Artifacts) Ids() []string {
+	ids := make([]string, 0, len(a))
+	for id := range a {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// Get returns the artifact named name, which is either the fully qualified name or the contract name
+// if only one contract has it
+func (a Artifacts) Get(name string) (*Artifact, error) {
+	if artifact, ok := a[name]; ok {
+		return artifact, nil
+	}
+	var found *Artifact
+	for _, id := range a.Ids() {
+		if a[id].ContractName != name {
+			continue
+		}
+		if found != nil {
+			return nil, fmt.Errorf("contract name %s is ambiguous, use file.sol:%s", name, name)
+		}
+		found = a[id]
+	}
+	if found == nil {
+		return nil, fmt.Errorf("contract %s not found", name)
+	}
+	return found, nil
+}
+
+// findLinkReferences finds the library placeholders in bytecode, __$<34 hex of the hash of file.sol:Library>$__
+// since solc 0.5.0 and __<file.sol:Library padded with _>__ before. They are keyed by the placeholder
+// without the underscores, as the source of the library is unknown.
+func findLinkReferences(bytecode string) map[string]map[string][]compiler.Offset {
+	code := strings.TrimPrefix(bytecode, "0x")
+	var references map[string]map[string][]compiler.Offset
+	for i := 0; i+40 <= len(code); {
+		placeholder := code[i : i+40]
+		if !strings.HasPrefix(placeholder, "__") || !strings.HasSuffix(placeholder, "__") {
+			i += 2
+			continue
+		}
+		name := strings.Trim(placeholder, "_")
+		if references == nil {
+			references = map[string]map[string][]compiler.Offset{"": {}}
+		}
+		references[""][name] = append(references[""][name], compiler.Offset{Start: i / 2, Length: 20})
+		i += 40
+	}
+	return references
+}
+
+func hexPrefixed(code string) string {
+	if code == "" || strings.HasPrefix(code, "0x") {
+		return code
+	}
+	return "0x" + code
+}
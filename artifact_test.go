@@ -0,0 +1,173 @@
+package go_eth_client
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/meshplus/go-eth-client/compiler"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const mathPlaceholder = "__$6ad30996409d058139477db06ae39abaac$__"
+
+func artifactIds(artifacts []*Artifact) []string {
+	var ids []string
+	for _, artifact := range artifacts {
+		ids = append(ids, artifact.Id())
+	}
+	return ids
+}
+
+func TestLoadHardhatArtifact(t *testing.T) {
+	token, err := LoadHardhatArtifact("./testdata/artifacts/hardhat/artifacts/contracts/Token.sol/Token.json")
+	require.Nil(t, err)
+	assert.Equal(t, "contracts/Token.sol:Token", token.Id())
+	assert.True(t, token.Deployable())
+	assert.True(t, strings.HasPrefix(token.Bytecode, "0x603f"))
+	// the placeholder is kept at the referenced offset
+	assert.Equal(t, mathPlaceholder, token.Bytecode[2+12*2:2+32*2])
+	assert.Equal(t, mathPlaceholder, token.DeployedBytecode[2+1*2:2+21*2])
+	assert.Equal(t, map[string]map[string][]compiler.Offset{"contracts/Math.sol": {"Math": {{Start: 12, Length: 20}}}}, token.LinkReferences)
+	assert.Equal(t, map[string]map[string][]compiler.Offset{"contracts/Math.sol": {"Math": {{Start: 1, Length: 20}}}}, token.DeployedLinkReferences)
+	assert.Equal(t, map[string][]compiler.Offset{"7": {{Start: 25, Length: 32}}}, token.ImmutableReferences)
+
+	iface, err := LoadArtifact("./testdata/artifacts/hardhat/artifacts/contracts/IToken.sol/IToken.json")
+	require.Nil(t, err)
+	assert.Equal(t, "contracts/IToken.sol:IToken", iface.Id())
+	assert.False(t, iface.Deployable())
+	assert.Nil(t, iface.ImmutableReferences)
+
+	_, err = LoadHardhatArtifact("./testdata/artifacts/truffle/build/contracts/Token.json")
+	require.NotNil(t, err)
+}
+
+func TestLoadFoundryArtifact(t *testing.T) {
+	token, err := LoadArtifact("./testdata/artifacts/foundry/out/Token.sol/Token.json")
+	require.Nil(t, err)
+	assert.Equal(t, "src/Token.sol:Token", token.Id())
+	assert.Equal(t, map[string]map[string][]compiler.Offset{"src/Math.sol": {"Math": {{Start: 12, Length: 20}}}}, token.LinkReferences)
+	assert.Equal(t, map[string][]compiler.Offset{"7": {{Start: 25, Length: 32}}}, token.ImmutableReferences)
+	assert.True(t, strings.Contains(token.Bytecode, "__$22ef75b31e2d998cd01172b890884772a9$__"))
+
+	// metadata given as a string
+	math, err := LoadArtifact("./testdata/artifacts/foundry/out/Math.sol/Math.json")
+	require.Nil(t, err)
+	assert.Equal(t, "src/Math.sol:Math", math.Id())
+	assert.Equal(t, "0x600180600b6000396000f300", math.Bytecode)
+	assert.Equal(t, compiler.KindLibrary, math.Kind)
+}
+
+func TestLoadTruffleArtifact(t *testing.T) {
+	token, err := LoadArtifact("./testdata/artifacts/truffle/build/contracts/Token.json")
+	require.Nil(t, err)
+	assert.Equal(t, "contracts/Token.sol:Token", token.Id())
+	assert.Equal(t, map[string]map[string][]compiler.Offset{"": {"Math": {{Start: 12, Length: 20}}}}, token.LinkReferences)
+	assert.Equal(t, map[string]map[string][]compiler.Offset{"": {"Math": {{Start: 1, Length: 20}}}}, token.DeployedLinkReferences)
+	assert.Equal(t, map[string][]compiler.Offset{"7": {{Start: 25, Length: 32}}}, token.ImmutableReferences)
+
+	math, err := LoadTruffleArtifact("./testdata/artifacts/truffle/build/contracts/Math.json")
+	require.Nil(t, err)
+	assert.Nil(t, math.LinkReferences)
+	assert.Equal(t, compiler.KindLibrary, math.Kind)
+}
+
+func TestLoadAbiBin(t *testing.T) {
+	token, err := LoadAbiBin("./testdata/artifacts/abibin/Token.abi", "./testdata/artifacts/abibin/Token.bin")
+	require.Nil(t, err)
+	assert.Equal(t, "Token", token.Id())
+	assert.Equal(t, map[string]map[string][]compiler.Offset{"": {"$6ad30996409d058139477db06ae39abaac$": {{Start: 12, Length: 20}}}},
+		token.LinkReferences)
+
+	_, err = LoadAbiBin("./testdata/artifacts/abibin/Token.bin", "./testdata/artifacts/abibin/Token.bin")
+	require.NotNil(t, err)
+	_, err = LoadAbiBin("./testdata/artifacts/abibin/Orphan.abi", "./testdata/artifacts/abibin/Orphan.bin")
+	require.NotNil(t, err)
+}
+
+func TestLoadArtifacts(t *testing.T) {
+	for dir, ids := range map[string][]string{
+		"./testdata/artifacts/hardhat/artifacts": {"contracts/IToken.sol:IToken", "contracts/Math.sol:Math", "contracts/Token.sol:Token"},
+		"./testdata/artifacts/foundry/out":       {"src/Math.sol:Math", "src/Token.sol:Token"},
+		"./testdata/artifacts/truffle/build":     {"contracts/Math.sol:Math", "contracts/Token.sol:Token"},
+		"./testdata/artifacts/abibin":            {"Math", "Token"},
+	} {
+		artifacts, err := LoadArtifacts(dir)
+		require.Nil(t, err)
+		assert.Equal(t, ids, artifactIds(artifacts), dir)
+	}
+	_, err := LoadArtifacts("./testdata/artifacts/missing")
+	require.NotNil(t, err)
+
+	// other json files are skipped, broken artifacts are not
+	dir := t.TempDir()
+	require.Nil(t, ioutil.WriteFile(filepath.Join(dir, "cache.json"), []byte(`{"files":{}}`), 0644))
+	require.Nil(t, ioutil.WriteFile(filepath.Join(dir, "list.json"), []byte(`[1]`), 0644))
+	artifacts, err := LoadArtifacts(dir)
+	require.Nil(t, err)
+	assert.Empty(t, artifacts)
+	require.Nil(t, ioutil.WriteFile(filepath.Join(dir, "Token.json"), []byte(`{"_format":"hh-sol-artifact-1","abi":[`), 0644))
+	_, err = LoadArtifacts(dir)
+	require.NotNil(t, err)
+	assert.Contains(t, err.Error(), "Token.json")
+	require.Nil(t, ioutil.WriteFile(filepath.Join(dir, "Token.json"), []byte(`{"abi":[],"bytecode":"0x"}`), 0644))
+	_, err = LoadArtifacts(dir)
+	require.NotNil(t, err)
+}
+
+func TestArtifacts(t *testing.T) {
+	hardhat, err := LoadArtifacts("./testdata/artifacts/hardhat/artifacts")
+	require.Nil(t, err)
+	foundry, err := LoadArtifacts("./testdata/artifacts/foundry/out")
+	require.Nil(t, err)
+	artifacts := NewArtifacts(append(hardhat, foundry...)...)
+	assert.Equal(t, []string{"contracts/IToken.sol:IToken", "contracts/Math.sol:Math", "contracts/Token.sol:Token",
+		"src/Math.sol:Math", "src/Token.sol:Token"}, artifacts.Ids())
+
+	token, err := artifacts.Get("src/Token.sol:Token")
+	require.Nil(t, err)
+	assert.Equal(t, "src/Token.sol:Token", token.Id())
+	iface, err := artifacts.Get("IToken")
+	require.Nil(t, err)
+	assert.Equal(t, "contracts/IToken.sol:IToken", iface.Id())
+	_, err = artifacts.Get("Token")
+	require.NotNil(t, err)
+	assert.Contains(t, err.Error(), "ambiguous")
+	_, err = artifacts.Get("Missing")
+	require.NotNil(t, err)
+
+	// the result is ordered by the names whatever the order of the artifacts
+	result := NewCompileResult(foundry[1], hardhat[2], foundry[0])
+	assert.Equal(t, []string{"contracts/Token.sol:Token", "src/Math.sol:Math", "src/Token.sol:Token"}, result.Names)
+	assert.Equal(t, hardhat[2].Bytecode, result.Bin[0])
+
+	// a result filled by hand
+	byHand := &CompileResult{Abi: []string{"[]", "[]"}, Bin: []string{"0x", "6001"}, Names: []string{"a.sol:A", "B"}}
+	b, err := byHand.artifacts().Get("B")
+	require.Nil(t, err)
+	assert.Equal(t, "0x6001", b.Bytecode)
+	a, err := byHand.artifacts().Get("A")
+	require.Nil(t, err)
+	assert.Equal(t, "a.sol", a.SourceName)
+	assert.False(t, byHand.deployable(0))
+	assert.True(t, byHand.deployable(1))
+}
+
+func TestSimulatedDeployArtifact(t *testing.T) {
+	sim, _ := newSimulated(t)
+	defer sim.Stop()
+
+	artifacts, err := LoadArtifacts("./testdata/artifacts/hardhat/artifacts")
+	require.Nil(t, err)
+	result := NewCompileResult(artifacts[1])
+	require.Equal(t, []string{"contracts/Math.sol:Math"}, result.Names)
+	addresses, err := sim.DeployWithReceipt(nil, result, nil)
+	require.Nil(t, err)
+	require.Equal(t, 1, len(addresses))
+	code, err := sim.EthGetCode(common.HexToAddress(addresses[0]), nil)
+	require.Nil(t, err)
+	require.Equal(t, "00", code)
+}
@@ -8,23 +8,38 @@ import (
 	"github.com/ethereum/go-ethereum/accounts/abi"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/meshplus/go-eth-client/compiler"
+	"github.com/meshplus/go-eth-client/signer"
 )
 
 type Client interface {
 	Compile(sourceFiles ...string) (*CompileResult, error)
+	CompileSources(sources map[string]string, importer compiler.ImportFunc) (*CompileResult, error)
 	Deploy(privKey *ecdsa.PrivateKey, result *CompileResult, args []interface{}, opts ...TransactionOption) ([]string, error)
 	DeployWithReceipt(privKey *ecdsa.PrivateKey, result *CompileResult, args []interface{}, opts ...TransactionOption) ([]string, error)
+	DeployAll(privKey *ecdsa.PrivateKey, result *CompileResult, args []interface{}, opts ...TransactionOption) ([]*DeploymentResult, error)
+	DeployContracts(privKey *ecdsa.PrivateKey, result *CompileResult, args map[string][]interface{}, opts ...TransactionOption) (map[string]string, error)
+	DeployWithLibraries(privKey *ecdsa.PrivateKey, result *CompileResult, name string, args []interface{}, libraries map[string]string, opts ...TransactionOption) (map[string]string, error)
 	DeployByCode(privKey *ecdsa.PrivateKey, abi abi.ABI, code string, args []interface{}, opts ...TransactionOption) (string, uint64, error)
+	DeployByCodeWithResult(privKey *ecdsa.PrivateKey, contractAbi abi.ABI, code string, args []interface{}, opts ...TransactionOption) (*DeploymentResult, error)
+	DeployCreate2(privKey *ecdsa.PrivateKey, name string, salt common.Hash, contractAbi abi.ABI, code string, args []interface{}, opts ...TransactionOption) (*DeploymentResult, error)
+	PredictCreate2(salt common.Hash, contractAbi abi.ABI, code string, args []interface{}) (common.Address, error)
+	PredictNextAddress(privKey *ecdsa.PrivateKey, opts ...TransactionOption) (common.Address, error)
+	Migrate(privKey *ecdsa.PrivateKey, migrations []Migration, opts ...TransactionOption) ([]*MigrationResult, error)
+	Manifest() *Manifest
 	Invoke(privKey *ecdsa.PrivateKey, contractAbi *abi.ABI, address string, method string, args []interface{}, opts ...TransactionOption) ([]interface{}, error)
 	InvokeWithReceipt(privKey *ecdsa.PrivateKey, contractAbi *abi.ABI, address string, method string, args []interface{}, opts ...TransactionOption) ([]interface{}, error)
+	InvokeWithResult(privKey *ecdsa.PrivateKey, contractAbi *abi.ABI, address string, method string, args []interface{}, opts ...TransactionOption) (*InvocationResult, error)
 	EthCall(contractAbi *abi.ABI, address string, method string, args []interface{}) ([]interface{}, error)
 	EthGasPrice() (*big.Int, error)
 	EthGetTransactionReceipt(hash common.Hash) (*types.Receipt, error)
 	EthGetTransactionCount(account common.Address, blockNumber *big.Int) (uint64, error)
 	EthGetBalance(account common.Address, blockNumber *big.Int) (*big.Int, error)
 	EthSendTransaction(privKey *ecdsa.PrivateKey, transaction *types.Transaction) (common.Hash, error)
+	EthSendTransactionBySigner(txSigner signer.Signer, transaction *types.Transaction) (common.Hash, error)
 	EthSendRawTransaction(transaction *types.Transaction) (common.Hash, error)
 	EthSendTransactionWithReceipt(privKey *ecdsa.PrivateKey, transaction *types.Transaction) (*types.Receipt, error)
+	EthSendTransactionWithReceiptBySigner(txSigner signer.Signer, transaction *types.Transaction) (*types.Receipt, error)
 	EthSendRawTransactionWithReceipt(transaction *types.Transaction) (*types.Receipt, error)
 	EthGetCode(account common.Address, blockNumber *big.Int) (string, error)
 	EthGetBlockByNumber(blockNumber *big.Int, fullTx bool) (*types.Block, error)
@@ -0,0 +1,105 @@
+package compiler
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// Logger reports the cache hits and misses, the Logger of go_eth_client satisfies it
+type Logger interface {
+	Debugf(format string, args ...interface{})
+	Infof(format string, args ...interface{})
+}
+
+// Cache keeps the outputs of solc by the hash of the sources, the settings and the compiler version,
+// in memory and optionally in a directory shared by processes. As the sources hold every imported
+// file, changing any of them changes the key.
+type Cache struct {
+	dir    string
+	mu     sync.Mutex
+	memory map[string][]byte
+	hits   int
+	misses int
+}
+
+// NewCache creates a cache storing the outputs in dir, which is created if it doesn't exist.
+// The outputs are only kept in memory if dir is empty.
+func NewCache(dir string) (*Cache, error) {
+	if dir != "" {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return nil, fmt.Errorf("create compile cache: %w", err)
+		}
+	}
+	return &Cache{dir: dir, memory: make(map[string][]byte)}, nil
+}
+
+// Stats returns the numbers of hits and misses
+func (c *Cache) Stats() (hits, misses int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.hits, c.misses
+}
+
+// get returns the raw solc output stored with key
+func (c *Cache) get(key string) ([]byte, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if raw, ok := c.memory[key]; ok {
+		c.hits++
+		return raw, true
+	}
+	if c.dir != "" {
+		if raw, err := ioutil.ReadFile(c.path(key)); err == nil && json.Valid(raw) {
+			c.memory[key] = raw
+			c.hits++
+			return raw, true
+		}
+	}
+	c.misses++
+	return nil, false
+}
+
+func (c *Cache) put(key string, raw []byte) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.memory[key] = raw
+	if c.dir == "" {
+		return nil
+	}
+	// write to a temporary file first so that no process reads a partial output
+	tmp, err := ioutil.TempFile(c.dir, key+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("write compile cache: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(raw); err != nil {
+		tmp.Close()
+		return fmt.Errorf("write compile cache: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("write compile cache: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), c.path(key)); err != nil {
+		return fmt.Errorf("write compile cache: %w", err)
+	}
+	return nil
+}
+
+func (c *Cache) path(key string) string {
+	return filepath.Join(c.dir, key+".json")
+}
+
+// cacheKey hashes the standard-json input, which holds the sources and the settings, with the version of solc
+func cacheKey(version string, input []byte) string {
+	hash := sha256.New()
+	hash.Write([]byte(version))
+	hash.Write([]byte{0})
+	hash.Write(input)
+	return hex.EncodeToString(hash.Sum(nil))
+}
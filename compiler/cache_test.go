@@ -0,0 +1,134 @@
+package compiler
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/meshplus/go-eth-client/internal/compilertest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testLogger struct {
+	mu       sync.Mutex
+	messages []string
+}
+
+func (l *testLogger) Debugf(format string, args ...interface{}) {
+	l.Infof(format, args...)
+}
+
+func (l *testLogger) Infof(format string, args ...interface{}) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.messages = append(l.messages, fmt.Sprintf(format, args...))
+}
+
+func (l *testLogger) count(prefix string) int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	n := 0
+	for _, message := range l.messages {
+		if strings.HasPrefix(message, prefix) {
+			n++
+		}
+	}
+	return n
+}
+
+func TestMemoryCache(t *testing.T) {
+	solc := compilertest.NewSolc(t, "")
+	cache, err := NewCache("")
+	require.Nil(t, err)
+	logger := &testLogger{}
+	c := New(WithSolc(solc.Path), WithCache(cache), WithLogger(logger))
+
+	first, err := c.CompileFiles("../testdata/storage.sol")
+	require.Nil(t, err)
+	second, err := c.CompileFiles("../testdata/storage.sol")
+	require.Nil(t, err)
+	assert.Equal(t, first, second)
+	assert.Equal(t, 1, len(solc.Calls(t)))
+	hits, misses := cache.Stats()
+	assert.Equal(t, 1, hits)
+	assert.Equal(t, 1, misses)
+	assert.Equal(t, 1, logger.count("Compile cache hit"))
+	assert.Equal(t, 1, logger.count("Compile cache miss"))
+
+	// outputs are not shared between hits
+	second.Contracts["../testdata/storage.sol"]["Storage"].Bytecode.Object = ""
+	third, err := c.CompileFiles("../testdata/storage.sol")
+	require.Nil(t, err)
+	assert.Equal(t, first, third)
+
+	// other settings are compiled again
+	_, err = New(WithSolc(solc.Path), WithCache(cache), WithOptimizer(200)).CompileFiles("../testdata/storage.sol")
+	require.Nil(t, err)
+	assert.Equal(t, 2, len(solc.Calls(t)))
+}
+
+func TestDiskCache(t *testing.T) {
+	solc := compilertest.NewSolc(t, "")
+	dir := t.TempDir()
+	src := t.TempDir()
+	token := filepath.Join(src, "Token.sol")
+	lib := filepath.Join(src, "lib", "Math.sol")
+	require.Nil(t, ioutil.WriteFile(token, []byte("import \"./lib/Math.sol\";\ncontract Token {}\n"), 0644))
+	require.Nil(t, writeFile(lib, "library Math {}\n"))
+
+	compile := func(solc *compilertest.Solc) (*Output, *Cache) {
+		// a new cache as another process would open
+		cache, err := NewCache(dir)
+		require.Nil(t, err)
+		out, err := New(WithSolc(solc.Path), WithCache(cache)).CompileFiles(token)
+		require.Nil(t, err)
+		return out, cache
+	}
+	first, _ := compile(solc)
+	assert.Equal(t, 1, len(solc.Calls(t)))
+	_, err := first.Contract("Math")
+	require.Nil(t, err)
+
+	second, cache := compile(solc)
+	assert.Equal(t, first, second)
+	assert.Equal(t, 1, len(solc.Calls(t)))
+	hits, _ := cache.Stats()
+	assert.Equal(t, 1, hits)
+
+	// a change of an imported file is a miss
+	require.Nil(t, writeFile(lib, "library Math {}\nlibrary SafeMath {}\n"))
+	third, cache := compile(solc)
+	assert.Equal(t, 2, len(solc.Calls(t)))
+	_, misses := cache.Stats()
+	assert.Equal(t, 1, misses)
+	_, err = third.Contract("SafeMath")
+	require.Nil(t, err)
+
+	// so is another version of solc
+	other := compilertest.NewSolc(t, "0.8.20+commit.a1b79de6.Linux.g++")
+	out, _ := compile(other)
+	assert.Equal(t, 1, len(other.Calls(t)))
+	assert.Equal(t, "0.8.20+commit.a1b79de6.Linux.g++", out.Version)
+
+	// failed compilations are not cached
+	require.Nil(t, writeFile(lib, "library Math {\n// error: expected '}'\n"))
+	cache, err = NewCache(dir)
+	require.Nil(t, err)
+	for i := 0; i < 2; i++ {
+		_, err = New(WithSolc(solc.Path), WithCache(cache)).CompileFiles(token)
+		require.NotNil(t, err)
+	}
+	assert.Equal(t, 4, len(solc.Calls(t)))
+}
+
+func writeFile(path, content string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, []byte(content), 0644)
+}
@@ -0,0 +1,325 @@
+// Package compiler drives solc through its standard-JSON interface.
+//
+// A Compiler holds the solc binary and the settings of the compilations, such as
+// the optimizer, the EVM version and the remappings:
+//
+//	c := compiler.New(compiler.WithSolc("/usr/local/bin/solc-0.8.19"), compiler.WithOptimizer(200))
+//	out, _ := c.CompileFiles("contracts/broker.sol")
+//	broker, _ := out.Contract("contracts/broker.sol:Broker")
+//
+// Every output selected by DefaultOutputSelection is returned per contract, solc errors
+// are returned as a *CompileError with the file and line of each message. With WithCache,
+// solc only runs for sources, settings or versions it hasn't compiled before.
+package compiler
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const defaultSolc = "solc"
+
+var (
+	versionRegexp  = regexp.MustCompile(`Version: (\S+)`)
+	positionRegexp = regexp.MustCompile(`-->\s*(.+?):(\d+):(\d+):`)
+)
+
+// Compiler compiles Solidity with one solc binary and one set of settings
+type Compiler struct {
+	solc         string   // solc的路径
+	settings     Settings // standard-JSON输入中的编译设置
+	basePath     string   // solc的--base-path
+	includePaths []string // solc的--include-path
+	allowPaths   []string // solc的--allow-paths
+	cache        *Cache   // 编译结果缓存，为空时每次都运行solc
+	logger       Logger
+
+	versionOnce sync.Once
+	version     string
+	versionErr  error
+}
+
+type Option func(*Compiler)
+
+// WithSolc sets the path of the solc binary, solc in PATH is used by default
+func WithSolc(path string) Option {
+	return func(c *Compiler) {
+		c.solc = path
+	}
+}
+
+// WithSettings replaces the settings of the compilations
+func WithSettings(settings Settings) Option {
+	return func(c *Compiler) {
+		c.settings = settings
+	}
+}
+
+// WithOptimizer enables the optimizer with runs
+func WithOptimizer(runs int) Option {
+	return func(c *Compiler) {
+		c.settings.Optimizer = Optimizer{Enabled: true, Runs: runs}
+	}
+}
+
+// WithEVMVersion sets the target EVM version, such as london or paris
+func WithEVMVersion(version string) Option {
+	return func(c *Compiler) {
+		c.settings.EVMVersion = version
+	}
+}
+
+// WithRemappings adds import remappings, such as @openzeppelin/=node_modules/@openzeppelin/
+func WithRemappings(remappings ...string) Option {
+	return func(c *Compiler) {
+		c.settings.Remappings = append(c.settings.Remappings, remappings...)
+	}
+}
+
+// WithBasePath sets the directory solc resolves imports from
+func WithBasePath(dir string) Option {
+	return func(c *Compiler) {
+		c.basePath = dir
+	}
+}
+
+// WithIncludePaths adds directories solc looks for imports in besides the base path, it needs solc 0.8.8 or later
+func WithIncludePaths(dirs ...string) Option {
+	return func(c *Compiler) {
+		c.includePaths = append(c.includePaths, dirs...)
+	}
+}
+
+// WithAllowPaths adds directories solc is allowed to read imports from
+func WithAllowPaths(dirs ...string) Option {
+	return func(c *Compiler) {
+		c.allowPaths = append(c.allowPaths, dirs...)
+	}
+}
+
+// WithCache reuses the outputs in cache for the same sources, settings and compiler version
+func WithCache(cache *Cache) Option {
+	return func(c *Compiler) {
+		c.cache = cache
+	}
+}
+
+// WithLogger sets the logger which reports the cache hits and misses
+func WithLogger(logger Logger) Option {
+	return func(c *Compiler) {
+		c.logger = logger
+	}
+}
+
+func New(opts ...Option) *Compiler {
+	c := &Compiler{}
+	for _, opt := range opts {
+		opt(c)
+	}
+	if c.solc == "" {
+		c.solc = defaultSolc
+	}
+	if c.settings.OutputSelection == nil {
+		c.settings.OutputSelection = DefaultOutputSelection
+	}
+	return c
+}
+
+// Settings returns the settings of the compilations
+func (c *Compiler) Settings() Settings {
+	return c.settings
+}
+
+// Version returns the full version of solc, such as 0.8.19+commit.7dd6d404.Linux.g++
+func (c *Compiler) Version() (string, error) {
+	c.versionOnce.Do(func() {
+		out, err := exec.Command(c.solc, "--version").Output()
+		if err != nil {
+			c.versionErr = fmt.Errorf("run %s --version: %w", c.solc, err)
+			return
+		}
+		matches := versionRegexp.FindSubmatch(out)
+		if matches == nil {
+			c.versionErr = fmt.Errorf("unknown version of %s: %s", c.solc, out)
+			return
+		}
+		c.version = string(matches[1])
+	})
+	return c.version, c.versionErr
+}
+
+// CompileFiles compiles the Solidity files, the source names of the output are the cleaned paths of files.
+// The imports are read from the base path and the include paths the way solc reads them.
+func (c *Compiler) CompileFiles(files ...string) (*Output, error) {
+	sources := make(map[string]string, len(files))
+	for _, file := range files {
+		content, err := ioutil.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("read source: %w", err)
+		}
+		sources[filepath.ToSlash(filepath.Clean(file))] = string(content)
+	}
+	return c.CompileSources(sources, c.fileImporter)
+}
+
+// CompileInput compiles a standard-JSON input as it is
+func (c *Compiler) CompileInput(input *Input) (*Output, error) {
+	version, err := c.Version()
+	if err != nil {
+		return nil, err
+	}
+	data, err := json.Marshal(input)
+	if err != nil {
+		return nil, fmt.Errorf("encode standard-json input: %w", err)
+	}
+	var key string
+	if c.cache != nil {
+		key = cacheKey(version, data)
+		if raw, ok := c.cache.get(key); ok {
+			c.logf("Compile cache hit %s", key)
+			return parseOutput(raw, input, version)
+		}
+		c.logf("Compile cache miss %s", key)
+	}
+	raw, err := c.run(data)
+	if err != nil {
+		return nil, err
+	}
+	output, err := parseOutput(raw, input, version)
+	if err != nil {
+		return nil, err
+	}
+	if c.cache != nil {
+		if err := c.cache.put(key, raw); err != nil {
+			return nil, err
+		}
+	}
+	return output, nil
+}
+
+// fileImporter reads the source unit name relative to the base path and then to the include paths
+func (c *Compiler) fileImporter(name string) (string, error) {
+	path := filepath.FromSlash(name)
+	if filepath.IsAbs(path) {
+		content, err := ioutil.ReadFile(path)
+		return string(content), err
+	}
+	for _, dir := range append([]string{c.basePath}, c.includePaths...) {
+		content, err := ioutil.ReadFile(filepath.Join(dir, path))
+		if err == nil {
+			return string(content), nil
+		}
+		if !os.IsNotExist(err) {
+			return "", err
+		}
+	}
+	return "", fmt.Errorf("source %s is not found: %w", name, fs.ErrNotExist)
+}
+
+func (c *Compiler) logf(format string, args ...interface{}) {
+	if c.logger != nil {
+		c.logger.Infof(format, args...)
+	}
+}
+
+func (c *Compiler) run(input []byte) ([]byte, error) {
+	args := []string{"--standard-json"}
+	if c.basePath != "" {
+		args = append(args, "--base-path", c.basePath)
+	}
+	for _, dir := range c.includePaths {
+		args = append(args, "--include-path", dir)
+	}
+	if len(c.allowPaths) != 0 {
+		args = append(args, "--allow-paths", strings.Join(c.allowPaths, ","))
+	}
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command(c.solc, args...)
+	cmd.Stdin = bytes.NewReader(input)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil && stdout.Len() == 0 {
+		return nil, fmt.Errorf("run %s: %w: %s", c.solc, err, strings.TrimSpace(stderr.String()))
+	}
+	return stdout.Bytes(), nil
+}
+
+func parseOutput(raw []byte, input *Input, version string) (*Output, error) {
+	var out output
+	if err := json.Unmarshal(raw, &out); err != nil {
+		return nil, fmt.Errorf("decode standard-json output: %w", err)
+	}
+	result := &Output{
+		Version:   version,
+		Contracts: make(map[string]map[string]*Contract),
+		Sources:   out.Sources,
+	}
+	failed := false
+	for _, e := range out.Errors {
+		err := e.Error
+		if e.SourceLocation != nil && e.SourceLocation.File != "" {
+			err.File, err.Start, err.End = e.SourceLocation.File, e.SourceLocation.Start, e.SourceLocation.End
+			err.Line, err.Column = position(input.Sources[err.File].Content, err.Start)
+		}
+		// the sources solc read itself are not known, take the position from the message
+		if err.File == "" || err.Line == 0 {
+			if matches := positionRegexp.FindStringSubmatch(err.FormattedMessage); matches != nil {
+				err.File = matches[1]
+				err.Line, _ = strconv.Atoi(matches[2])
+				err.Column, _ = strconv.Atoi(matches[3])
+			}
+		}
+		failed = failed || err.IsError()
+		result.Errors = append(result.Errors, err)
+	}
+	if failed {
+		return nil, &CompileError{Errors: result.Errors}
+	}
+
+	for file, contracts := range out.Contracts {
+		var unit SourceUnit
+		if ast := out.Sources[file].Ast; len(ast) != 0 {
+			if err := json.Unmarshal(ast, &unit); err != nil {
+				return nil, fmt.Errorf("decode ast of %s: %w", file, err)
+			}
+		}
+		result.Contracts[file] = make(map[string]*Contract, len(contracts))
+		for name, contract := range contracts {
+			definition, _ := unit.Contract(name)
+			result.Contracts[file][name] = &Contract{
+				File:              file,
+				Name:              name,
+				Abi:               contract.Abi,
+				Metadata:          contract.Metadata,
+				Bytecode:          contract.Evm.Bytecode,
+				DeployedBytecode:  contract.Evm.DeployedBytecode,
+				MethodIdentifiers: contract.Evm.MethodIdentifiers,
+				StorageLayout:     contract.StorageLayout,
+				Kind:              definition.ContractKind,
+				Abstract:          definition.Abstract,
+			}
+		}
+	}
+	return result, nil
+}
+
+// position returns the line and column of offset in content, both start from 1, they are 0 if content is unknown
+func position(content string, offset int) (int, int) {
+	if content == "" || offset < 0 || offset > len(content) {
+		return 0, 0
+	}
+	before := content[:offset]
+	line := strings.Count(before, "\n") + 1
+	column := offset - strings.LastIndex(before, "\n")
+	return line, column
+}
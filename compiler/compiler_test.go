@@ -0,0 +1,215 @@
+package compiler
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/meshplus/go-eth-client/internal/compilertest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestSolcStandIn runs the solc stand-in of compilertest
+func TestSolcStandIn(t *testing.T) {
+	compilertest.Main()
+}
+
+func TestCompileFiles(t *testing.T) {
+	solc := compilertest.NewSolc(t, "")
+	c := New(
+		WithSolc(solc.Path),
+		WithOptimizer(200),
+		WithEVMVersion("london"),
+		WithRemappings("@openzeppelin/=node_modules/@openzeppelin/"),
+		WithBasePath("../testdata"),
+		WithIncludePaths("node_modules"),
+		WithAllowPaths("/tmp", "../testdata"),
+	)
+	version, err := c.Version()
+	require.Nil(t, err)
+	assert.Equal(t, compilertest.DefaultVersion, version)
+
+	out, err := c.CompileFiles("../testdata/storage.sol")
+	require.Nil(t, err)
+	assert.Equal(t, compilertest.DefaultVersion, out.Version)
+	assert.Empty(t, out.Warnings())
+
+	storage, err := out.Contract("../testdata/storage.sol:Storage")
+	require.Nil(t, err)
+	assert.Equal(t, "../testdata/storage.sol:Storage", storage.Id())
+	code, err := ioutil.ReadFile("../testdata/storage.bin")
+	require.Nil(t, err)
+	assert.Equal(t, string(code)[2:], storage.Bytecode.Object)
+	assert.Equal(t, string(code)[2+0x0b*2:], storage.DeployedBytecode.Object)
+	assert.NotEmpty(t, storage.Bytecode.SourceMap)
+	assert.NotEmpty(t, storage.DeployedBytecode.SourceMap)
+	assert.JSONEq(t, readFile(t, "../testdata/storage.abi"), string(storage.Abi))
+	assert.Equal(t, map[string]string{"retrieve()": "2e64cec1", "store(uint256)": "6057361d"}, storage.MethodIdentifiers)
+	require.NotNil(t, storage.StorageLayout)
+	assert.Equal(t, "number", storage.StorageLayout.Storage[0].Label)
+	assert.Equal(t, "uint256", storage.StorageLayout.Types[storage.StorageLayout.Storage[0].Type].Label)
+	var metadata struct {
+		Compiler struct {
+			Version string `json:"version"`
+		} `json:"compiler"`
+	}
+	require.Nil(t, json.Unmarshal([]byte(storage.Metadata), &metadata))
+	assert.NotEmpty(t, metadata.Compiler.Version)
+	_, err = out.Contract("Storage")
+	require.Nil(t, err)
+	_, err = out.Contract("Broker")
+	require.NotNil(t, err)
+
+	// solc gets the sources and the settings as standard-json
+	calls := solc.Calls(t)
+	require.Equal(t, 1, len(calls))
+	assert.Equal(t, []string{"--standard-json", "--base-path", "../testdata", "--include-path", "node_modules",
+		"--allow-paths", "/tmp,../testdata"}, calls[0].Args)
+	var input Input
+	require.Nil(t, json.Unmarshal(calls[0].Input, &input))
+	assert.Equal(t, "Solidity", input.Language)
+	assert.Equal(t, readFile(t, "../testdata/storage.sol"), input.Sources["../testdata/storage.sol"].Content)
+	assert.Equal(t, Optimizer{Enabled: true, Runs: 200}, input.Settings.Optimizer)
+	assert.Equal(t, "london", input.Settings.EVMVersion)
+	assert.Equal(t, []string{"@openzeppelin/=node_modules/@openzeppelin/"}, input.Settings.Remappings)
+	assert.Equal(t, DefaultOutputSelection, input.Settings.OutputSelection)
+}
+
+func TestCompileErrors(t *testing.T) {
+	solc := compilertest.NewSolc(t, "")
+	dir := t.TempDir()
+	file := filepath.Join(dir, "broken.sol")
+	source := "pragma solidity ^0.8.0;\n\ncontract Broken {\n    // warning: unused variable\n  // error: expected ';'\n}\n"
+	require.Nil(t, ioutil.WriteFile(file, []byte(source), 0644))
+
+	_, err := New(WithSolc(solc.Path)).CompileFiles(file)
+	require.NotNil(t, err)
+	var compileErr *CompileError
+	require.True(t, errors.As(err, &compileErr))
+	require.Equal(t, 2, len(compileErr.Errors))
+	warning, failure := compileErr.Errors[0], compileErr.Errors[1]
+	assert.False(t, warning.IsError())
+	assert.Equal(t, 4, warning.Line)
+	assert.Equal(t, 5, warning.Column)
+	assert.True(t, failure.IsError())
+	assert.Equal(t, filepath.ToSlash(file), failure.File)
+	assert.Equal(t, 5, failure.Line)
+	assert.Equal(t, 3, failure.Column)
+	assert.Equal(t, "ParserError", failure.Type)
+	assert.Contains(t, err.Error(), filepath.ToSlash(file)+":5:3: ParserError: expected ';'")
+	assert.NotContains(t, err.Error(), "unused variable")
+
+	// warnings alone don't fail the compilation
+	require.Nil(t, ioutil.WriteFile(file, []byte("contract Warned {\n    // warning: shadowed\n}\n"), 0644))
+	out, err := New(WithSolc(solc.Path)).CompileFiles(file)
+	require.Nil(t, err)
+	require.Equal(t, 1, len(out.Warnings()))
+	assert.Equal(t, "shadowed", out.Warnings()[0].Message)
+	assert.Equal(t, 2, out.Warnings()[0].Line)
+
+	_, err = New(WithSolc(filepath.Join(dir, "missing-solc"))).CompileFiles(file)
+	require.NotNil(t, err)
+	_, err = New(WithSolc(solc.Path)).CompileFiles(filepath.Join(dir, "missing.sol"))
+	require.NotNil(t, err)
+}
+
+func TestPosition(t *testing.T) {
+	line, column := position("ab\ncd\n", 4)
+	assert.Equal(t, 2, line)
+	assert.Equal(t, 2, column)
+	line, column = position("ab", 0)
+	assert.Equal(t, 1, line)
+	assert.Equal(t, 1, column)
+	line, _ = position("", 3)
+	assert.Equal(t, 0, line)
+}
+
+// lookSolc returns the installed solc, the test is skipped if there is none
+func lookSolc(t *testing.T) string {
+	path, err := exec.LookPath("solc")
+	if err != nil {
+		t.Skip("solc is not installed")
+	}
+	return path
+}
+
+// TestCompileWithSolc runs the installed solc if there is one
+func TestCompileWithSolc(t *testing.T) {
+	out, err := New(WithSolc(lookSolc(t)), WithOptimizer(200)).CompileFiles("../testdata/storage.sol")
+	require.Nil(t, err)
+	storage, err := out.Contract("Storage")
+	require.Nil(t, err)
+	assert.NotEmpty(t, storage.Bytecode.Object)
+	assert.NotEmpty(t, storage.DeployedBytecode.SourceMap)
+	assert.Equal(t, "2e64cec1", storage.MethodIdentifiers["retrieve()"])
+	assert.Equal(t, "number", storage.StorageLayout.Storage[0].Label)
+}
+
+func TestSolcLinkReferences(t *testing.T) {
+	out, err := New(WithSolc(lookSolc(t))).CompileSources(map[string]string{
+		"math.sol": "// SPDX-License-Identifier: MIT\npragma solidity >=0.7.0;\n" +
+			"library Math {\n    function add(uint a, uint b) external pure returns (uint) {\n        return a + b;\n    }\n}\n",
+		"user.sol": "// SPDX-License-Identifier: MIT\npragma solidity >=0.7.0;\nimport \"math.sol\";\n" +
+			"contract User {\n    function sum(uint a, uint b) external pure returns (uint) {\n        return Math.add(a, b);\n    }\n}\n",
+	}, nil)
+	require.Nil(t, err)
+	user, err := out.Contract("user.sol:User")
+	require.Nil(t, err)
+	offsets := user.Bytecode.LinkReferences["math.sol"]["Math"]
+	require.NotEmpty(t, offsets)
+	for _, offset := range offsets {
+		assert.Equal(t, 20, offset.Length)
+		assert.Equal(t, "__$", user.Bytecode.Object[2*offset.Start:2*offset.Start+3])
+	}
+	math, err := out.Contract("math.sol:Math")
+	require.Nil(t, err)
+	assert.Empty(t, math.Bytecode.LinkReferences)
+}
+
+func TestSolcImmutableReferences(t *testing.T) {
+	out, err := New(WithSolc(lookSolc(t))).CompileSources(map[string]string{
+		"owned.sol": "// SPDX-License-Identifier: MIT\npragma solidity >=0.7.0;\n" +
+			"contract Owned {\n    address public immutable owner;\n    constructor() {\n        owner = msg.sender;\n    }\n}\n",
+	}, nil)
+	require.Nil(t, err)
+	owned, err := out.Contract("Owned")
+	require.Nil(t, err)
+	require.Len(t, owned.DeployedBytecode.ImmutableReferences, 1)
+	for _, offsets := range owned.DeployedBytecode.ImmutableReferences {
+		require.NotEmpty(t, offsets)
+		for _, offset := range offsets {
+			assert.Equal(t, 32, offset.Length)
+		}
+	}
+	assert.Empty(t, owned.Bytecode.ImmutableReferences)
+}
+
+func TestSolcErrors(t *testing.T) {
+	_, err := New(WithSolc(lookSolc(t))).CompileSources(map[string]string{
+		"broken.sol": "// SPDX-License-Identifier: MIT\npragma solidity >=0.7.0;\n" +
+			"contract Broken {\n    function get() external pure returns (uint) {\n        return \"text\";\n    }\n}\n",
+	}, nil)
+	var compileErr *CompileError
+	require.True(t, errors.As(err, &compileErr))
+	var e Error
+	for _, e = range compileErr.Errors {
+		if e.IsError() {
+			break
+		}
+	}
+	assert.Equal(t, "TypeError", e.Type)
+	assert.Equal(t, "broken.sol", e.File)
+	assert.Equal(t, 5, e.Line)
+	assert.Equal(t, 16, e.Column)
+	assert.True(t, e.End > e.Start)
+}
+
+func readFile(t *testing.T, path string) string {
+	data, err := ioutil.ReadFile(path)
+	require.Nil(t, err)
+	return string(data)
+}
@@ -0,0 +1,160 @@
+package compiler
+
+import (
+	"fmt"
+	"io/fs"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+var (
+	commentRegexp = regexp.MustCompile(`(?s)/\*.*?\*/|//[^\n]*`)
+	importRegexp  = regexp.MustCompile(`\bimport\s+(?:[^"';]*\s)?["']([^"']+)["'][^;]*;`)
+)
+
+// ImportFunc returns the content of the source unit name, such as @openzeppelin/contracts/access/Ownable.sol,
+// relative imports are resolved and remappings are applied before it is called
+type ImportFunc func(name string) (string, error)
+
+// FSImporter resolves imports from fsys, such as an embed.FS holding the dependencies
+func FSImporter(fsys fs.FS) ImportFunc {
+	return func(name string) (string, error) {
+		if !fs.ValidPath(name) {
+			return "", fmt.Errorf("invalid source name %s", name)
+		}
+		data, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			return "", err
+		}
+		return string(data), nil
+	}
+}
+
+// DirImporter resolves imports from the directories in order, such as node_modules or lib
+func DirImporter(dirs ...string) ImportFunc {
+	return func(name string) (string, error) {
+		if !fs.ValidPath(name) {
+			return "", fmt.Errorf("invalid source name %s", name)
+		}
+		for _, dir := range dirs {
+			data, err := ioutil.ReadFile(filepath.Join(dir, filepath.FromSlash(name)))
+			if err == nil {
+				return string(data), nil
+			}
+			if !os.IsNotExist(err) {
+				return "", err
+			}
+		}
+		return "", fmt.Errorf("%s is not found in %s: %w", name, strings.Join(dirs, ", "), fs.ErrNotExist)
+	}
+}
+
+// Importers tries the importers in order until one of them finds the source
+func Importers(importers ...ImportFunc) ImportFunc {
+	return func(name string) (string, error) {
+		var errs []string
+		for _, importer := range importers {
+			content, err := importer(name)
+			if err == nil {
+				return content, nil
+			}
+			errs = append(errs, err.Error())
+		}
+		return "", fmt.Errorf("import %s: %s", name, strings.Join(errs, "; "))
+	}
+}
+
+// CompileSources compiles the sources given as source unit names and contents, the imports
+// which are not among the sources are loaded by importer
+func (c *Compiler) CompileSources(sources map[string]string, importer ImportFunc) (*Output, error) {
+	resolved, err := c.ResolveImports(sources, importer)
+	if err != nil {
+		return nil, err
+	}
+	input := &Input{Language: "Solidity", Sources: make(map[string]Source, len(resolved)), Settings: c.settings}
+	for name, content := range resolved {
+		input.Sources[name] = Source{Content: content}
+	}
+	return c.CompileInput(input)
+}
+
+// ResolveImports returns the sources with all the sources they import, directly or not
+func (c *Compiler) ResolveImports(sources map[string]string, importer ImportFunc) (map[string]string, error) {
+	resolved := make(map[string]string, len(sources))
+	var queue []string
+	for name, content := range sources {
+		resolved[name] = content
+		queue = append(queue, name)
+	}
+	sort.Strings(queue)
+	for len(queue) != 0 {
+		name := queue[0]
+		queue = queue[1:]
+		for _, imported := range Imports(resolved[name]) {
+			unit := c.sourceUnitName(name, imported)
+			if _, ok := resolved[unit]; ok {
+				continue
+			}
+			if importer == nil {
+				return nil, fmt.Errorf("import %s from %s: no importer", unit, name)
+			}
+			content, err := importer(unit)
+			if err != nil {
+				return nil, fmt.Errorf("import %s from %s: %w", unit, name, err)
+			}
+			resolved[unit] = content
+			queue = append(queue, unit)
+		}
+	}
+	return resolved, nil
+}
+
+// Imports returns the import paths of a source as they are written
+func Imports(content string) []string {
+	var imports []string
+	for _, match := range importRegexp.FindAllStringSubmatch(commentRegexp.ReplaceAllString(content, ""), -1) {
+		imports = append(imports, match[1])
+	}
+	return imports
+}
+
+// sourceUnitName resolves the import path of the source unit importer as solc does:
+// relative paths are joined to the directory of importer first, then remappings are applied
+func (c *Compiler) sourceUnitName(importer, importPath string) string {
+	name := importPath
+	if strings.HasPrefix(importPath, "./") || strings.HasPrefix(importPath, "../") {
+		name = path.Join(path.Dir(importer), importPath)
+	}
+	return remap(c.settings.Remappings, importer, name)
+}
+
+// remap applies the remapping [context:]prefix=target with the longest context and prefix matching
+func remap(remappings []string, importer, name string) string {
+	var context, prefix, target string
+	matched := false
+	for _, remapping := range remappings {
+		r, t := remapping, ""
+		if i := strings.Index(r, "="); i >= 0 {
+			r, t = r[:i], r[i+1:]
+		}
+		ctx, p := "", r
+		if i := strings.Index(r, ":"); i >= 0 {
+			ctx, p = r[:i], r[i+1:]
+		}
+		if p == "" || !strings.HasPrefix(importer, ctx) || !strings.HasPrefix(name, p) {
+			continue
+		}
+		if !matched || len(ctx) > len(context) || (len(ctx) == len(context) && len(p) > len(prefix)) {
+			context, prefix, target, matched = ctx, p, t, true
+		}
+	}
+	if !matched {
+		return name
+	}
+	return target + strings.TrimPrefix(name, prefix)
+}
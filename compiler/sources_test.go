@@ -0,0 +1,134 @@
+package compiler
+
+import (
+	"encoding/json"
+	"io/fs"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+	"testing/fstest"
+
+	"github.com/meshplus/go-eth-client/internal/compilertest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestImports(t *testing.T) {
+	source := `pragma solidity ^0.8.0;
+import "./Plain.sol";
+import './Single.sol';
+import "./Alias.sol" as Alias;
+import * as Star from "../Star.sol";
+import {A, B as C} from "@openzeppelin/contracts/Named.sol";
+import {
+    D
+} from "lib/Multiline.sol";
+// import "./Commented.sol";
+/* import "./Block.sol"; */
+contract Importer {}
+`
+	assert.Equal(t, []string{"./Plain.sol", "./Single.sol", "./Alias.sol", "../Star.sol",
+		"@openzeppelin/contracts/Named.sol", "lib/Multiline.sol"}, Imports(source))
+}
+
+func TestRemap(t *testing.T) {
+	remappings := []string{
+		"@openzeppelin/=node_modules/@openzeppelin/",
+		"@openzeppelin/contracts/=lib/oz/contracts/",
+		"legacy/:@openzeppelin/=lib/oz-v3/",
+	}
+	assert.Equal(t, "lib/oz/contracts/token/ERC20.sol", remap(remappings, "Token.sol", "@openzeppelin/contracts/token/ERC20.sol"))
+	assert.Equal(t, "node_modules/@openzeppelin/other/A.sol", remap(remappings, "Token.sol", "@openzeppelin/other/A.sol"))
+	assert.Equal(t, "lib/oz-v3/contracts/A.sol", remap(remappings, "legacy/Token.sol", "@openzeppelin/contracts/A.sol"))
+	assert.Equal(t, "ds-test/test.sol", remap(remappings, "Token.sol", "ds-test/test.sol"))
+
+	c := New(WithRemappings(remappings...))
+	assert.Equal(t, "contracts/lib/Math.sol", c.sourceUnitName("contracts/token/Token.sol", "../lib/Math.sol"))
+	assert.Equal(t, "contracts/token/IToken.sol", c.sourceUnitName("contracts/token/Token.sol", "./IToken.sol"))
+	assert.Equal(t, "node_modules/@openzeppelin/A.sol", c.sourceUnitName("contracts/token/Token.sol", "@openzeppelin/A.sol"))
+}
+
+func TestCompileSources(t *testing.T) {
+	solc := compilertest.NewSolc(t, "")
+	deps := fstest.MapFS{
+		"@openzeppelin/contracts/access/Ownable.sol": {Data: []byte(`import "../utils/Context.sol";
+abstract contract Ownable is Context {}
+`)},
+		"@openzeppelin/contracts/utils/Context.sol": {Data: []byte("abstract contract Context {}\n")},
+	}
+	vendor := t.TempDir()
+	require.Nil(t, os.MkdirAll(filepath.Join(vendor, "solmate"), 0755))
+	require.Nil(t, ioutil.WriteFile(filepath.Join(vendor, "solmate", "Math.sol"), []byte("library Math {}\n"), 0644))
+
+	var imported []string
+	importer := Importers(FSImporter(deps), DirImporter(vendor))
+	out, err := New(WithSolc(solc.Path)).CompileSources(map[string]string{
+		"gen/Token.sol": `import "@openzeppelin/contracts/access/Ownable.sol";
+import {Math} from "solmate/Math.sol";
+import "./IToken.sol";
+contract Token is Ownable, IToken {}
+`,
+		"gen/IToken.sol": "interface IToken {}\n",
+	}, func(name string) (string, error) {
+		imported = append(imported, name)
+		return importer(name)
+	})
+	require.Nil(t, err)
+	sort.Strings(imported)
+	assert.Equal(t, []string{"@openzeppelin/contracts/access/Ownable.sol", "@openzeppelin/contracts/utils/Context.sol",
+		"solmate/Math.sol"}, imported)
+
+	calls := solc.Calls(t)
+	require.Equal(t, 1, len(calls))
+	var input Input
+	require.Nil(t, json.Unmarshal(calls[0].Input, &input))
+	var names []string
+	for name := range input.Sources {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	assert.Equal(t, []string{"@openzeppelin/contracts/access/Ownable.sol", "@openzeppelin/contracts/utils/Context.sol",
+		"gen/IToken.sol", "gen/Token.sol", "solmate/Math.sol"}, names)
+
+	token, err := out.Contract("gen/Token.sol:Token")
+	require.Nil(t, err)
+	assert.NotEmpty(t, token.Bytecode.Object)
+	assert.Equal(t, KindContract, token.Kind)
+	assert.True(t, token.Deployable())
+	ownable, err := out.Contract("@openzeppelin/contracts/access/Ownable.sol:Ownable")
+	require.Nil(t, err)
+	assert.True(t, ownable.Abstract)
+	assert.False(t, ownable.Deployable())
+	iface, err := out.Contract("IToken")
+	require.Nil(t, err)
+	assert.Equal(t, KindInterface, iface.Kind)
+	assert.False(t, iface.Deployable())
+	math, err := out.Contract("Math")
+	require.Nil(t, err)
+	assert.Equal(t, KindLibrary, math.Kind)
+	assert.True(t, math.Deployable())
+}
+
+func TestCompileSourcesWithRemappings(t *testing.T) {
+	solc := compilertest.NewSolc(t, "")
+	deps := fstest.MapFS{"lib/oz/Ownable.sol": {Data: []byte("contract Ownable {}\n")}}
+	c := New(WithSolc(solc.Path), WithRemappings("@oz/=lib/oz/"))
+	out, err := c.CompileSources(map[string]string{
+		"Token.sol": "import \"@oz/Ownable.sol\";\ncontract Token is Ownable {}\n",
+	}, FSImporter(deps))
+	require.Nil(t, err)
+	_, err = out.Contract("lib/oz/Ownable.sol:Ownable")
+	require.Nil(t, err)
+
+	_, err = c.CompileSources(map[string]string{"Token.sol": "import \"@oz/Missing.sol\";\n"}, FSImporter(deps))
+	require.NotNil(t, err)
+	assert.ErrorIs(t, err, fs.ErrNotExist)
+	assert.Contains(t, err.Error(), "import lib/oz/Missing.sol from Token.sol")
+	_, err = c.CompileSources(map[string]string{"Token.sol": "import \"../../etc/passwd\";\n"}, DirImporter(t.TempDir()))
+	require.NotNil(t, err)
+	_, err = c.CompileSources(map[string]string{"Token.sol": "import \"./Missing.sol\";\n"}, nil)
+	require.NotNil(t, err)
+	assert.Equal(t, 1, len(solc.Calls(t)))
+}
@@ -0,0 +1,264 @@
+package compiler
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Optimizer is the optimizer section of the settings
+type Optimizer struct {
+	Enabled bool `json:"enabled"`
+	Runs    int  `json:"runs,omitempty"`
+}
+
+// Settings is the settings section of the standard-JSON input
+type Settings struct {
+	Remappings      []string                       `json:"remappings,omitempty"`
+	Optimizer       Optimizer                      `json:"optimizer"`
+	EVMVersion      string                         `json:"evmVersion,omitempty"`
+	ViaIR           bool                           `json:"viaIR,omitempty"`
+	Libraries       map[string]map[string]string   `json:"libraries,omitempty"` // file -> library -> address
+	Metadata        *MetadataSettings              `json:"metadata,omitempty"`
+	OutputSelection map[string]map[string][]string `json:"outputSelection"`
+}
+
+// MetadataSettings is the metadata section of the settings
+type MetadataSettings struct {
+	UseLiteralContent bool   `json:"useLiteralContent,omitempty"`
+	BytecodeHash      string `json:"bytecodeHash,omitempty"` // ipfs, bzzr1 or none
+}
+
+// DefaultOutputSelection selects everything Contract holds
+var DefaultOutputSelection = map[string]map[string][]string{
+	"*": {
+		"*": {"abi", "metadata", "storageLayout", "evm.bytecode", "evm.deployedBytecode", "evm.methodIdentifiers"},
+		"":  {"ast"},
+	},
+}
+
+// Source is a source unit of the standard-JSON input, given by content or by urls solc reads itself
+type Source struct {
+	Content string   `json:"content,omitempty"`
+	Urls    []string `json:"urls,omitempty"`
+}
+
+// Input is the standard-JSON input of solc
+type Input struct {
+	Language string            `json:"language"`
+	Sources  map[string]Source `json:"sources"`
+	Settings Settings          `json:"settings"`
+}
+
+// Output is the result of a compilation
+type Output struct {
+	Version   string                          // version of solc, such as 0.8.19+commit.7dd6d404
+	Contracts map[string]map[string]*Contract // source name -> contract name -> contract
+	Sources   map[string]SourceOutput         // source name -> id and ast
+	Errors    []Error                         // all messages of solc, see Warnings
+}
+
+// SourceOutput is the output of a source unit
+type SourceOutput struct {
+	Id  int             `json:"id"`
+	Ast json.RawMessage `json:"ast,omitempty"`
+}
+
+// Contract is the output of a contract
+type Contract struct {
+	File              string            // source name of the contract
+	Name              string            // name of the contract
+	Abi               json.RawMessage   // abi as json
+	Metadata          string            // metadata json, as it is embedded into the bytecode
+	Bytecode          Bytecode          // creation bytecode
+	DeployedBytecode  Bytecode          // runtime bytecode
+	MethodIdentifiers map[string]string // function signature -> selector in hex
+	StorageLayout     *StorageLayout
+	Kind              string // contract, library or interface, empty if the ast is not selected
+	Abstract          bool   // whether it is an abstract contract
+}
+
+// Id returns the key of the contract, file.sol:Contract
+func (c *Contract) Id() string {
+	return c.File + ":" + c.Name
+}
+
+// Deployable reports whether the contract can be deployed, interfaces and abstract contracts can't
+func (c *Contract) Deployable() bool {
+	return c.Kind != KindInterface && !c.Abstract && c.Bytecode.Object != ""
+}
+
+// Kinds of the contract definitions
+const (
+	KindContract  = "contract"
+	KindLibrary   = "library"
+	KindInterface = "interface"
+)
+
+// SourceUnit is the part of the ast of a source unit telling the contracts it declares
+type SourceUnit struct {
+	AbsolutePath string    `json:"absolutePath"`
+	Nodes        []AstNode `json:"nodes"`
+}
+
+// AstNode is a top level node of a source unit, the contract fields are only set for contract definitions
+type AstNode struct {
+	NodeType     string `json:"nodeType"`
+	Name         string `json:"name"`
+	ContractKind string `json:"contractKind"`
+	Abstract     bool   `json:"abstract"`
+}
+
+// Contract returns the definition of the contract, library or interface declared as name
+func (s *SourceUnit) Contract(name string) (AstNode, bool) {
+	for _, node := range s.Nodes {
+		if node.NodeType == "ContractDefinition" && node.Name == name {
+			return node, true
+		}
+	}
+	return AstNode{}, false
+}
+
+// Bytecode is a creation or runtime bytecode with its source map and link information
+type Bytecode struct {
+	Object              string                         `json:"object"` // hex without 0x, it may contain library placeholders
+	Opcodes             string                         `json:"opcodes,omitempty"`
+	SourceMap           string                         `json:"sourceMap,omitempty"`
+	LinkReferences      map[string]map[string][]Offset `json:"linkReferences,omitempty"`      // file -> library -> offsets
+	ImmutableReferences map[string][]Offset            `json:"immutableReferences,omitempty"` // ast id -> offsets
+	GeneratedSources    []json.RawMessage              `json:"generatedSources,omitempty"`
+	FunctionDebugData   map[string]json.RawMessage     `json:"functionDebugData,omitempty"`
+}
+
+// Offset is the position of a placeholder in a bytecode, in bytes
+type Offset struct {
+	Start  int `json:"start"`
+	Length int `json:"length"`
+}
+
+// StorageLayout is the layout of the state variables of a contract
+type StorageLayout struct {
+	Storage []StorageSlot          `json:"storage"`
+	Types   map[string]StorageType `json:"types"`
+}
+
+// StorageSlot is the position of a state variable
+type StorageSlot struct {
+	AstId    int    `json:"astId"`
+	Contract string `json:"contract"`
+	Label    string `json:"label"`
+	Offset   int    `json:"offset"`
+	Slot     string `json:"slot"`
+	Type     string `json:"type"`
+}
+
+// StorageType describes a type of the storage layout
+type StorageType struct {
+	Encoding      string        `json:"encoding"`
+	Label         string        `json:"label"`
+	NumberOfBytes string        `json:"numberOfBytes"`
+	Base          string        `json:"base,omitempty"`
+	Key           string        `json:"key,omitempty"`
+	Value         string        `json:"value,omitempty"`
+	Members       []StorageSlot `json:"members,omitempty"`
+}
+
+// Error is an error or a warning reported by solc
+type Error struct {
+	Severity         string `json:"severity"` // error, warning or info
+	Type             string `json:"type"`     // such as TypeError or ParserError
+	Component        string `json:"component"`
+	ErrorCode        string `json:"errorCode,omitempty"`
+	Message          string `json:"message"`
+	FormattedMessage string `json:"formattedMessage,omitempty"`
+	File             string `json:"-"` // source name of the position, empty if the error has no position
+	Line             int    `json:"-"` // line of the position, starting from 1
+	Column           int    `json:"-"` // column of the position, starting from 1
+	Start            int    `json:"-"` // byte offset of the position
+	End              int    `json:"-"`
+}
+
+func (e Error) Error() string {
+	if e.File == "" {
+		return fmt.Sprintf("%s: %s", e.Type, e.Message)
+	}
+	return fmt.Sprintf("%s:%d:%d: %s: %s", e.File, e.Line, e.Column, e.Type, e.Message)
+}
+
+// IsError reports whether e fails the compilation
+func (e Error) IsError() bool {
+	return e.Severity == "error"
+}
+
+// CompileError is returned if solc reports any errors, it holds the warnings too
+type CompileError struct {
+	Errors []Error
+}
+
+func (e *CompileError) Error() string {
+	var messages []string
+	for _, err := range e.Errors {
+		if err.IsError() {
+			messages = append(messages, err.Error())
+		}
+	}
+	return "compile contract: " + strings.Join(messages, "; ")
+}
+
+// Warnings returns the messages of solc which are not errors
+func (o *Output) Warnings() []Error {
+	var warnings []Error
+	for _, err := range o.Errors {
+		if !err.IsError() {
+			warnings = append(warnings, err)
+		}
+	}
+	return warnings
+}
+
+// Contract returns the contract with the id file.sol:Contract, or with the name if it is unique
+func (o *Output) Contract(id string) (*Contract, error) {
+	var found []*Contract
+	for file, contracts := range o.Contracts {
+		for name, contract := range contracts {
+			if id == file+":"+name || id == name {
+				found = append(found, contract)
+			}
+		}
+	}
+	switch len(found) {
+	case 0:
+		return nil, fmt.Errorf("contract %s not found", id)
+	case 1:
+		return found[0], nil
+	default:
+		return nil, fmt.Errorf("contract name %s is ambiguous, use file.sol:%s", id, id)
+	}
+}
+
+// output is the standard-JSON output of solc
+type output struct {
+	Errors    []outputError                        `json:"errors"`
+	Sources   map[string]SourceOutput              `json:"sources"`
+	Contracts map[string]map[string]outputContract `json:"contracts"`
+}
+
+type outputError struct {
+	Error
+	SourceLocation *struct {
+		File  string `json:"file"`
+		Start int    `json:"start"`
+		End   int    `json:"end"`
+	} `json:"sourceLocation"`
+}
+
+type outputContract struct {
+	Abi           json.RawMessage `json:"abi"`
+	Metadata      string          `json:"metadata"`
+	StorageLayout *StorageLayout  `json:"storageLayout"`
+	Evm           struct {
+		Bytecode          Bytecode          `json:"bytecode"`
+		DeployedBytecode  Bytecode          `json:"deployedBytecode"`
+		MethodIdentifiers map[string]string `json:"methodIdentifiers"`
+	} `json:"evm"`
+}
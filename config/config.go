@@ -1,10 +1,13 @@
 package config
 
 import (
+	"crypto/tls"
+	"crypto/x509"
 	"fmt"
 	"io/ioutil"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/spf13/viper"
 )
@@ -14,22 +17,90 @@ const configName = "bitxhub.toml"
 type JsonRpc struct {
 	Addrs     []string `mapstructure:"http_addrs" toml:"http_addrs" json:"http_addrs"`
 	GrpcAddrs []string `mapstructure:"grpc_addrs" toml:"grpc_addrs" json:"grpc_addrs"`
+	IpcAddrs  []string `mapstructure:"ipc_addrs" toml:"ipc_addrs" json:"ipc_addrs"`
+}
+
+type TLS struct {
+	CAFile             string `mapstructure:"ca_file" toml:"ca_file" json:"ca_file"`
+	CertFile           string `mapstructure:"cert_file" toml:"cert_file" json:"cert_file"`
+	KeyFile            string `mapstructure:"key_file" toml:"key_file" json:"key_file"`
+	ServerName         string `mapstructure:"server_name" toml:"server_name" json:"server_name"`
+	InsecureSkipVerify bool   `mapstructure:"insecure_skip_verify" toml:"insecure_skip_verify" json:"insecure_skip_verify"`
+}
+
+type Endpoint struct {
+	Url         string            `mapstructure:"url" toml:"url" json:"url"`
+	Headers     map[string]string `mapstructure:"headers" toml:"headers" json:"headers"`
+	BearerToken string            `mapstructure:"bearer_token" toml:"bearer_token" json:"bearer_token"`
+}
+
+type Transport struct {
+	Timeout     time.Duration     `mapstructure:"timeout" toml:"timeout" json:"timeout"`
+	Proxy       string            `mapstructure:"proxy" toml:"proxy" json:"proxy"`
+	Headers     map[string]string `mapstructure:"headers" toml:"headers" json:"headers"`
+	BearerToken string            `mapstructure:"bearer_token" toml:"bearer_token" json:"bearer_token"`
+	TLS         TLS               `mapstructure:"tls" toml:"tls" json:"tls"`
+	Endpoints   []Endpoint        `mapstructure:"endpoints" toml:"endpoints" json:"endpoints"`
+}
+
+// Account is the default account which signs the transactions sent without a private key,
+// relative paths are relative to the directory of the config file
+type Account struct {
+	Keystore     string `mapstructure:"keystore" toml:"keystore" json:"keystore"`
+	Password     string `mapstructure:"password" toml:"password" json:"password"`
+	PasswordFile string `mapstructure:"password_file" toml:"password_file" json:"password_file"`
 }
 
 type Config struct {
-	JsonRpc `mapstructure:"json_rpc" toml:"json_rpc" json:"json_rpc"`
+	JsonRpc   `mapstructure:"json_rpc" toml:"json_rpc" json:"json_rpc"`
+	Transport `mapstructure:"transport" toml:"transport" json:"transport"`
+	Account   `mapstructure:"account" toml:"account" json:"account"`
 }
 
 func DefaultConfig() *Config {
 	return &Config{
-		JsonRpc{
+		JsonRpc: JsonRpc{
 			Addrs: []string{"http://localhost:8881", "http://localhost:8882", "http://localhost:8883", "http://localhost:8884"},
 		},
 	}
 }
 
+// LoadTLSConfig builds the tls config from the configured files, it returns nil if nothing is configured
+func (t *TLS) LoadTLSConfig() (*tls.Config, error) {
+	if t.CAFile == "" && t.CertFile == "" && t.KeyFile == "" && t.ServerName == "" && !t.InsecureSkipVerify {
+		return nil, nil
+	}
+	tlsConfig := &tls.Config{
+		ServerName:         t.ServerName,
+		InsecureSkipVerify: t.InsecureSkipVerify,
+	}
+	if t.CAFile != "" {
+		caData, err := ioutil.ReadFile(t.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("read ca file error: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(caData) {
+			return nil, fmt.Errorf("no certificate found in ca file %s", t.CAFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+	if t.CertFile != "" || t.KeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(t.CertFile, t.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("load client certificate error: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+	return tlsConfig, nil
+}
+
 func UnmarshalConfig(repoPath, configPath string) (*Config, error) {
 	v := viper.New()
+	configDir := repoPath
+	if len(configPath) != 0 {
+		configDir = filepath.Dir(configPath)
+	}
 	if len(configPath) == 0 {
 		viper.SetConfigFile(filepath.Join(repoPath, configName))
 	} else {
@@ -59,12 +130,21 @@ func UnmarshalConfig(repoPath, configPath string) (*Config, error) {
 	}
 
 	// reading configuration will not cover the default configuration when type is string slice
-	if len(config.Addrs) == 0 {
+	if len(config.Addrs) == 0 && len(config.IpcAddrs) == 0 {
 		config.Addrs = []string{"http://localhost:8881", "http://localhost:8882", "http://localhost:8883", "http://localhost:8884"}
 	}
 
 	if len(config.GrpcAddrs) == 0 {
 		config.GrpcAddrs = []string{"localhost:60011"}
 	}
+	config.Account.Keystore = resolvePath(configDir, config.Account.Keystore)
+	config.Account.PasswordFile = resolvePath(configDir, config.Account.PasswordFile)
 	return config, nil
 }
+
+func resolvePath(dir, path string) string {
+	if path == "" || filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(dir, path)
+}
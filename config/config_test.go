@@ -2,14 +2,49 @@ package config
 
 import (
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
 
 func TestReadConfig(t *testing.T) {
 	path := "../testdata/config/bitxhub.toml"
-	config, err := UnmarshalConfig("", path)
+	config, err := UnmarshalConfig(t.TempDir(), path)
 	assert.Nil(t, err)
 	assert.Equal(t, 4, len(config.Addrs))
 	assert.Equal(t, 4, len(config.GrpcAddrs))
 }
+
+func TestReadTransportConfig(t *testing.T) {
+	path := "../testdata/config/bitxhub.toml"
+	config, err := UnmarshalConfig(t.TempDir(), path)
+	assert.Nil(t, err)
+	assert.Equal(t, 30*time.Second, config.Transport.Timeout)
+	assert.Equal(t, "global-token", config.Transport.BearerToken)
+	assert.Equal(t, "bitxhub", config.Transport.Headers["x-api-key"])
+	assert.Equal(t, 1, len(config.Transport.Endpoints))
+	assert.Equal(t, "http://localhost:8881", config.Transport.Endpoints[0].Url)
+	assert.Equal(t, "node1-token", config.Transport.Endpoints[0].BearerToken)
+
+	tlsConfig, err := config.Transport.TLS.LoadTLSConfig()
+	assert.Nil(t, err)
+	assert.Nil(t, tlsConfig)
+}
+
+func TestReadAccountConfig(t *testing.T) {
+	path := "../testdata/config/bitxhub.toml"
+	config, err := UnmarshalConfig(t.TempDir(), path)
+	assert.Nil(t, err)
+	assert.Equal(t, "../testdata/config/account.key", config.Account.Keystore)
+	assert.Equal(t, "../testdata/config/password", config.Account.PasswordFile)
+	assert.Equal(t, "", config.Account.Password)
+}
+
+func TestReadDefaultConfig(t *testing.T) {
+	config, err := UnmarshalConfig(t.TempDir(), "./bitxhub.toml")
+	assert.Nil(t, err)
+	assert.Equal(t, 4, len(config.Addrs))
+	// no default account is shipped
+	assert.Equal(t, "", config.Account.Keystore)
+	assert.Equal(t, "", config.Transport.BearerToken)
+}
@@ -0,0 +1,205 @@
+package go_eth_client
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/meshplus/go-eth-client/utils"
+)
+
+var (
+	// DeterministicDeployer is the address of the deterministic deployment proxy, which is the same on every chain
+	// as it is deployed by a presigned transaction without chain ID. Called with a 32 bytes salt followed by the
+	// init code, it deploys the contract by CREATE2 and returns its address.
+	DeterministicDeployer = common.HexToAddress("0x4e59b44847b379578588920cA78FbF26c0B4956C")
+
+	// deterministicDeployerSigner is the one-time sender of deterministicDeployerTx
+	deterministicDeployerSigner = common.HexToAddress("0x3fAB184622Dc19b6109349B94811493BF2a45362")
+	// deterministicDeployerTx deploys the proxy, it costs 100000 gas at 100 gwei
+	deterministicDeployerTx = common.FromHex("0xf8a58085174876e800830186a08080b853604580600e600039806000f350fe7ff" +
+		"fffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffe03601600081602082378035828234f58015156039578182fd5b" +
+		"8082525050506014600cf31ba02222222222222222222222222222222222222222222222222222222222222222a0222222222222222222222" +
+		"2222222222222222222222222222222222222222222")
+)
+
+// unprotectedTxRejected is the error of geth refusing transactions without chain id over rpc
+const unprotectedTxRejected = "only replay-protected (EIP-155) transactions allowed over RPC"
+
+// PredictAddress returns the address of the contract deployed by sender with nonce through CREATE
+func PredictAddress(sender common.Address, nonce uint64) common.Address {
+	return crypto.CreateAddress(sender, nonce)
+}
+
+// PredictCreate2Address returns the address of the contract deployed by deployer with salt through CREATE2,
+// initCodeHash is the keccak256 hash of the creation code followed by the constructor arguments
+func PredictCreate2Address(deployer common.Address, salt common.Hash, initCodeHash common.Hash) common.Address {
+	return crypto.CreateAddress2(deployer, salt, initCodeHash.Bytes())
+}
+
+// InitCode returns the creation code followed by the packed constructor arguments
+func InitCode(contractAbi abi.ABI, code string, args ...interface{}) ([]byte, error) {
+	if err := checkLinked(code); err != nil {
+		return nil, err
+	}
+	packed, err := contractAbi.Pack("", args...)
+	if err != nil {
+		return nil, err
+	}
+	return append(common.FromHex(code), packed...), nil
+}
+
+// PredictNextAddress returns the address of the next contract the signer of privKey deploys through CREATE
+func (rpc *EthRPC) PredictNextAddress(privKey *ecdsa.PrivateKey, opts ...TransactionOption) (common.Address, error) {
+	transactionOpts := &TransactionOptions{}
+	for _, opt := range opts {
+		opt(transactionOpts)
+	}
+	txSigner, err := rpc.signerOf(privKey, transactionOpts)
+	if err != nil {
+		return common.Address{}, err
+	}
+	nonce := transactionOpts.Nonce
+	if nonce == 0 {
+		if nonce, err = rpc.EthGetTransactionCount(txSigner.Address(), nil); err != nil {
+			return common.Address{}, err
+		}
+	}
+	return PredictAddress(txSigner.Address(), nonce), nil
+}
+
+// PredictCreate2 returns the address DeployCreate2 deploys the contract to with salt and args
+func (rpc *EthRPC) PredictCreate2(salt common.Hash, contractAbi abi.ABI, code string, args []interface{}) (common.Address, error) {
+	initCode, err := InitCode(contractAbi, code, args...)
+	if err != nil {
+		return common.Address{}, err
+	}
+	return PredictCreate2Address(DeterministicDeployer, salt, crypto.Keccak256Hash(initCode)), nil
+}
+
+// EnsureDeterministicDeployer deploys the deterministic deployment proxy if it is missing, the one-time signer
+// of the proxy deployment is funded by the signer of privKey first.
+//
+// The proxy deployment is presigned without chain ID, nodes refusing such transactions over rpc, as geth does
+// unless it is run with --rpc.allow-unprotected-txs, fail it with an error telling so. The one-time signer is
+// funded by then, a later call through a node accepting the transaction doesn't fund it again.
+// The funding transaction is sent with the nonce of opts if there is one.
+func (rpc *EthRPC) EnsureDeterministicDeployer(privKey *ecdsa.PrivateKey, opts ...TransactionOption) error {
+	_, err := rpc.ensureDeterministicDeployer(privKey, opts...)
+	return err
+}
+
+// ensureDeterministicDeployer is EnsureDeterministicDeployer, it returns the options for the next transaction
+// of the signer, with the nonce after the funding transaction if it was sent with the nonce of opts
+func (rpc *EthRPC) ensureDeterministicDeployer(privKey *ecdsa.PrivateKey, opts ...TransactionOption) ([]TransactionOption, error) {
+	code, err := rpc.EthGetCode(DeterministicDeployer, nil)
+	if err != nil {
+		return opts, err
+	}
+	if code != "0x" {
+		return opts, nil
+	}
+
+	deployTx := new(types.Transaction)
+	if err := deployTx.UnmarshalBinary(deterministicDeployerTx); err != nil {
+		return opts, fmt.Errorf("decode deterministic deployer transaction: %w", err)
+	}
+	cost := new(big.Int).Mul(deployTx.GasPrice(), new(big.Int).SetUint64(deployTx.Gas()))
+	balance, err := rpc.EthGetBalance(deterministicDeployerSigner, nil)
+	if err != nil {
+		return opts, err
+	}
+	if balance.Cmp(cost) < 0 {
+		transactionOpts := &TransactionOptions{}
+		for _, opt := range opts {
+			opt(transactionOpts)
+		}
+		txSigner, err := rpc.signerOf(privKey, transactionOpts)
+		if err != nil {
+			return opts, err
+		}
+		nonce := transactionOpts.Nonce
+		if nonce == 0 {
+			if nonce, err = rpc.EthGetTransactionCount(txSigner.Address(), nil); err != nil {
+				return opts, err
+			}
+		}
+		price := transactionOpts.GasPrice
+		if price == nil {
+			if price, err = rpc.EthGasPrice(); err != nil {
+				return opts, err
+			}
+		}
+		fundTx := utils.NewTransaction(nonce, deterministicDeployerSigner, 21000, price, nil, new(big.Int).Sub(cost, balance))
+		receipt, err := rpc.EthSendTransactionWithReceiptBySigner(txSigner, fundTx)
+		if err != nil {
+			return opts, fmt.Errorf("fund deterministic deployer signer: %w", err)
+		}
+		if receipt.Status == types.ReceiptStatusFailed {
+			return opts, fmt.Errorf("fund deterministic deployer signer failed, tx hash is: %s", receipt.TxHash)
+		}
+		opts = nextNonce(opts, nonce)
+	}
+
+	receipt, err := rpc.EthSendRawTransactionWithReceipt(deployTx)
+	if err != nil && strings.Contains(err.Error(), unprotectedTxRejected) {
+		return opts, fmt.Errorf("deploy deterministic deployer: the node refuses the presigned transaction without chain id, "+
+			"run it with --rpc.allow-unprotected-txs or deploy the proxy through another node: %w", err)
+	}
+	if err != nil {
+		return opts, fmt.Errorf("deploy deterministic deployer: %w", err)
+	}
+	if receipt.Status == types.ReceiptStatusFailed {
+		return opts, fmt.Errorf("deploy deterministic deployer failed, tx hash is: %s", receipt.TxHash)
+	}
+	return opts, nil
+}
+
+// DeployCreate2 deploys code with args through the deterministic deployment proxy with salt, so that the address
+// only depends on salt and the init code, see PredictCreate2. The proxy is deployed first if it is missing.
+// The contract is recorded in the manifest by name, or by its address if name is empty.
+func (rpc *EthRPC) DeployCreate2(privKey *ecdsa.PrivateKey, name string, salt common.Hash, contractAbi abi.ABI, code string,
+	args []interface{}, opts ...TransactionOption) (*DeploymentResult, error) {
+
+	initCode, err := InitCode(contractAbi, code, args...)
+	if err != nil {
+		return nil, err
+	}
+	address := PredictCreate2Address(DeterministicDeployer, salt, crypto.Keccak256Hash(initCode))
+	if existing, err := rpc.EthGetCode(address, nil); err != nil {
+		return nil, err
+	} else if existing != "0x" {
+		return nil, fmt.Errorf("contract is already deployed at %s", address)
+	}
+	if opts, err = rpc.ensureDeterministicDeployer(privKey, opts...); err != nil {
+		return nil, err
+	}
+	txOpts, err := rpc.generateTxOpts(privKey, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	var tx *types.Transaction
+	if err := rpc.sendWrapper(func(ctx context.Context, client *clientConn) error {
+		var err error
+		proxy := bind.NewBoundContract(DeterministicDeployer, abi.ABI{}, client.conn, client.conn, client.conn)
+		tx, err = proxy.RawTransact(txOpts, append(salt.Bytes(), initCode...))
+		return err
+	}); err != nil {
+		return nil, err
+	}
+	res := &DeploymentResult{Name: name, Address: address}
+	res.TxHash, res.From, res.Nonce = tx.Hash(), txOpts.From, tx.Nonce()
+	if waited, err := rpc.waitDeployment(res, tx, &contractAbi); err != nil {
+		return waited, err
+	}
+	rpc.record(name, res, initCode, args)
+	return res, nil
+}
@@ -0,0 +1,137 @@
+package go_eth_client
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPredictAddress(t *testing.T) {
+	assert.Equal(t, common.HexToAddress("0xcd234a471b72ba2f1ccf0a70fcaba648a5eecd8d"),
+		PredictAddress(common.HexToAddress("0x6ac7ea33f8831ea9dcc53393aaa88b25a785dbf0"), 0))
+	// examples of EIP-1014
+	assert.Equal(t, common.HexToAddress("0x4D1A2e2bB4F88F0250f26Ffff098B0b30B26BF38"),
+		PredictCreate2Address(common.Address{}, common.Hash{}, crypto.Keccak256Hash([]byte{0})))
+	assert.Equal(t, common.HexToAddress("0xB928f69Bb1D91Cd65274e3c79d8986362984fDA3"),
+		PredictCreate2Address(common.HexToAddress("0xdeadbeef00000000000000000000000000000000"), common.Hash{},
+			crypto.Keccak256Hash([]byte{0})))
+
+	// the proxy is deployed by the first transaction of its signer
+	tx := new(types.Transaction)
+	require.Nil(t, tx.UnmarshalBinary(deterministicDeployerTx))
+	from, err := types.Sender(types.HomesteadSigner{}, tx)
+	require.Nil(t, err)
+	assert.Equal(t, deterministicDeployerSigner, from)
+	assert.Equal(t, DeterministicDeployer, PredictAddress(from, tx.Nonce()))
+
+	_, err = InitCode(abi.ABI{}, "0x73"+mathPlaceholder)
+	require.NotNil(t, err)
+}
+
+func TestSimulatedDeployCreate2(t *testing.T) {
+	sim, _ := newSimulated(t)
+	defer sim.Stop()
+
+	contractAbi, err := abi.JSON(strings.NewReader(storedAbi))
+	require.Nil(t, err)
+	next, err := sim.PredictNextAddress(nil)
+	require.Nil(t, err)
+	address, _, err := sim.DeployByCode(nil, contractAbi, storedCode(), nil)
+	require.Nil(t, err)
+	require.Equal(t, next.Hex(), address)
+
+	salt := common.HexToHash("0x01")
+	predicted, err := sim.PredictCreate2(salt, contractAbi, storedCode(), nil)
+	require.Nil(t, err)
+	res, err := sim.DeployCreate2(nil, "Stored", salt, contractAbi, storedCode(), nil)
+	require.Nil(t, err)
+	require.Equal(t, predicted, res.Address)
+	require.Equal(t, types.ReceiptStatusSuccessful, res.Status)
+	code, err := sim.EthGetCode(DeterministicDeployer, nil)
+	require.Nil(t, err)
+	require.NotEqual(t, "0x", code)
+	code, err = sim.EthGetCode(predicted, nil)
+	require.Nil(t, err)
+	require.NotEqual(t, "0x", code)
+	invocation, err := sim.InvokeWithResult(nil, &contractAbi, predicted.Hex(), "store", []interface{}{big.NewInt(1)})
+	require.Nil(t, err)
+	require.Equal(t, "Stored", invocation.Events[0].Name)
+
+	// the same salt and init code can only be deployed once
+	_, err = sim.DeployCreate2(nil, "Stored", salt, contractAbi, storedCode(), nil)
+	require.NotNil(t, err)
+	other, err := sim.DeployCreate2(nil, "", common.HexToHash("0x02"), contractAbi, storedCode(), nil)
+	require.Nil(t, err)
+	require.NotEqual(t, predicted, other.Address)
+}
+
+func TestSimulatedDeployCreate2WithNonce(t *testing.T) {
+	sim, _ := newSimulated(t)
+	defer sim.Stop()
+
+	contractAbi, err := abi.JSON(strings.NewReader(storedAbi))
+	require.Nil(t, err)
+	_, _, err = sim.DeployByCode(nil, contractAbi, storedCode(), nil)
+	require.Nil(t, err)
+	from := crypto.PubkeyToAddress(sim.privateKey.PublicKey)
+	nonce, err := sim.EthGetTransactionCount(from, nil)
+	require.Nil(t, err)
+
+	// the proxy is missing, the funding transaction takes the given nonce and the deployment the next one
+	res, err := sim.DeployCreate2(nil, "Stored", common.HexToHash("0x01"), contractAbi, storedCode(), nil, WithNonce(nonce))
+	require.Nil(t, err)
+	require.Equal(t, types.ReceiptStatusSuccessful, res.Status)
+	assert.Equal(t, nonce+1, res.Nonce)
+	next, err := sim.EthGetTransactionCount(from, nil)
+	require.Nil(t, err)
+	assert.Equal(t, nonce+2, next)
+}
+
+// protectedConn refuses transactions without chain id, as geth does by default
+type protectedConn struct {
+	*simulatedConn
+}
+
+func (c *protectedConn) SendTransaction(ctx context.Context, tx *types.Transaction) error {
+	if !tx.Protected() {
+		return errors.New(unprotectedTxRejected)
+	}
+	return c.simulatedConn.SendTransaction(ctx, tx)
+}
+
+func TestSimulatedDeterministicDeployerRejected(t *testing.T) {
+	sim, _ := newSimulated(t)
+	defer sim.Stop()
+
+	conn := &protectedConn{&simulatedConn{SimulatedBackend: sim.backend, sim: sim}}
+	protected, err := New(WithUrls([]string{simulatedUrl}), WithPriKey(sim.privateKey), withFactory(func() (Conn, string, error) {
+		return conn, simulatedUrl, nil
+	}))
+	require.Nil(t, err)
+	defer protected.Stop()
+
+	err = protected.EnsureDeterministicDeployer(nil)
+	require.NotNil(t, err)
+	assert.Contains(t, err.Error(), "--rpc.allow-unprotected-txs")
+	// the one-time signer is funded already, the proxy is deployed through a node accepting the transaction
+	// without funding it again
+	from := crypto.PubkeyToAddress(sim.privateKey.PublicKey)
+	before, err := sim.EthGetBalance(from, nil)
+	require.Nil(t, err)
+	require.Nil(t, sim.EnsureDeterministicDeployer(nil))
+	after, err := sim.EthGetBalance(from, nil)
+	require.Nil(t, err)
+	require.Equal(t, before, after)
+	code, err := sim.EthGetCode(DeterministicDeployer, nil)
+	require.Nil(t, err)
+	require.NotEqual(t, "0x", code)
+}
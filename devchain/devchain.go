@@ -0,0 +1,392 @@
+// Package devchain starts in-process go-ethereum nodes on ephemeral ports for integration tests.
+//
+// The nodes run a clique chain sealed by the first node, which seals a block as soon as it has
+// pending transactions, like geth --dev does. The others sync it over p2p on localhost, so a
+// transaction sent to any node is mined and its receipt is served by all of them shortly after.
+// Every node serves geth's eth, net and web3 apis over http and websocket on the same port, and
+// can be stopped and restarted on that port with its chain kept, to exercise failover.
+//
+// go-ethereum v1.10.6 links github.com/fjl/memsize, which Go 1.23 and later refuse to link
+// unless the tests are built with -ldflags=-checklinkname=0.
+package devchain
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"net/url"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/eth"
+	"github.com/ethereum/go-ethereum/eth/downloader"
+	"github.com/ethereum/go-ethereum/eth/ethconfig"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/node"
+	"github.com/ethereum/go-ethereum/p2p"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+const (
+	defaultNodes    = 1
+	defaultAccounts = 4
+	defaultGasLimit = 1000000000
+
+	// waitTimeout bounds waiting for peers and blocks
+	waitTimeout = 10 * time.Second
+)
+
+var (
+	defaultBalance = new(big.Int).Exp(big.NewInt(10), big.NewInt(30), nil)
+
+	// quietOnce lowers the level of the global logger, which the node package sets to info on import
+	quietOnce sync.Once
+)
+
+type config struct {
+	nodes    int
+	accounts int
+	balance  *big.Int
+	alloc    core.GenesisAlloc
+}
+
+type Option func(*config)
+
+func WithNodes(n int) Option {
+	return func(config *config) {
+		config.nodes = n
+	}
+}
+
+// WithAccounts sets the number of generated accounts funded in genesis
+func WithAccounts(n int) Option {
+	return func(config *config) {
+		config.accounts = n
+	}
+}
+
+func WithBalance(balance *big.Int) Option {
+	return func(config *config) {
+		config.balance = balance
+	}
+}
+
+// WithAlloc adds extra genesis allocation
+func WithAlloc(alloc core.GenesisAlloc) Option {
+	return func(config *config) {
+		config.alloc = alloc
+	}
+}
+
+// DevChain is a set of go-ethereum nodes running one clique chain
+type DevChain struct {
+	genesis  *core.Genesis
+	sealer   *ecdsa.PrivateKey // 第一个节点的出块账户
+	accounts []*ecdsa.PrivateKey
+	nodes    []*Node
+}
+
+// Node is one go-ethereum node of the dev chain
+type Node struct {
+	chain   *DevChain
+	index   int
+	dataDir string // 节点数据目录，重启后保留链数据及节点密钥
+	port    int    // http及websocket端口
+	p2pPort int
+	stack   *node.Node
+	backend *eth.Ethereum
+}
+
+// Start creates the chain and starts its nodes, connected with each other
+func Start(opts ...Option) (*DevChain, error) {
+	cfg := &config{
+		nodes:    defaultNodes,
+		accounts: defaultAccounts,
+		balance:  defaultBalance,
+	}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	quietOnce.Do(func() {
+		log.Root().SetHandler(log.LvlFilterHandler(log.LvlError, log.StreamHandler(os.Stderr, log.TerminalFormat(false))))
+	})
+	if cfg.nodes < 1 {
+		return nil, fmt.Errorf("a dev chain needs a node at least")
+	}
+
+	chain := &DevChain{}
+	var err error
+	if chain.sealer, err = crypto.GenerateKey(); err != nil {
+		return nil, err
+	}
+	sealer := crypto.PubkeyToAddress(chain.sealer.PublicKey)
+	alloc := core.GenesisAlloc{sealer: {Balance: cfg.balance}}
+	for addr, account := range cfg.alloc {
+		alloc[addr] = account
+	}
+	for i := 0; i < cfg.accounts; i++ {
+		key, err := crypto.GenerateKey()
+		if err != nil {
+			return nil, err
+		}
+		chain.accounts = append(chain.accounts, key)
+		alloc[crypto.PubkeyToAddress(key.PublicKey)] = core.GenesisAccount{Balance: cfg.balance}
+	}
+	chainConfig := *params.AllCliqueProtocolChanges
+	chainConfig.Clique = &params.CliqueConfig{Period: 0, Epoch: params.AllCliqueProtocolChanges.Clique.Epoch}
+	chain.genesis = &core.Genesis{
+		Config:     &chainConfig,
+		ExtraData:  append(append(make([]byte, 32), sealer[:]...), make([]byte, crypto.SignatureLength)...),
+		GasLimit:   defaultGasLimit,
+		BaseFee:    big.NewInt(params.InitialBaseFee),
+		Difficulty: big.NewInt(1),
+		Alloc:      alloc,
+	}
+
+	for i := 0; i < cfg.nodes; i++ {
+		dataDir, err := ioutil.TempDir("", "devchain")
+		if err != nil {
+			chain.Close()
+			return nil, err
+		}
+		chain.nodes = append(chain.nodes, &Node{chain: chain, index: i, dataDir: dataDir})
+	}
+	for _, n := range chain.nodes {
+		if err := n.Start(); err != nil {
+			chain.Close()
+			return nil, err
+		}
+	}
+	return chain, nil
+}
+
+// URLs returns the http endpoints of all nodes, they are usable with WithUrls
+func (c *DevChain) URLs() []string {
+	urls := make([]string, 0, len(c.nodes))
+	for _, n := range c.nodes {
+		urls = append(urls, n.URL())
+	}
+	return urls
+}
+
+// WSURLs returns the websocket endpoints of all nodes
+func (c *DevChain) WSURLs() []string {
+	urls := make([]string, 0, len(c.nodes))
+	for _, n := range c.nodes {
+		urls = append(urls, n.WSURL())
+	}
+	return urls
+}
+
+// Accounts returns the private keys of the accounts funded in genesis
+func (c *DevChain) Accounts() []*ecdsa.PrivateKey {
+	return c.accounts
+}
+
+func (c *DevChain) Node(i int) *Node {
+	return c.nodes[i]
+}
+
+// ChainID returns the chain id of the dev chain
+func (c *DevChain) ChainID() *big.Int {
+	return c.genesis.Config.ChainID
+}
+
+// Mine seals a new block, holding a transfer of the sealer to itself as clique doesn't seal empty
+// blocks, and waits for the running nodes to import it. The first node has to be running.
+func (c *DevChain) Mine() error {
+	sealer := c.nodes[0]
+	if sealer.backend == nil {
+		return fmt.Errorf("the sealing node is stopped")
+	}
+	address := crypto.PubkeyToAddress(c.sealer.PublicKey)
+	head := sealer.backend.BlockChain().CurrentBlock()
+	nonce := sealer.backend.TxPool().Nonce(address)
+	price := new(big.Int).Mul(head.BaseFee(), big.NewInt(2))
+	tx, err := types.SignTx(types.NewTransaction(nonce, address, common.Big0, params.TxGas, price, nil),
+		types.LatestSignerForChainID(c.ChainID()), c.sealer)
+	if err != nil {
+		return err
+	}
+	if err := sealer.backend.TxPool().AddLocal(tx); err != nil {
+		return err
+	}
+	for _, n := range c.nodes {
+		if err := n.waitBlock(head.NumberU64() + 1); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Close stops all nodes and removes their data
+func (c *DevChain) Close() {
+	for _, n := range c.nodes {
+		_ = n.Stop()
+		_ = os.RemoveAll(n.dataDir)
+	}
+}
+
+// Start starts the node and connects it with the running nodes. A restarted node listens on its
+// previous ports and goes on with the chain it had.
+func (n *Node) Start() error {
+	if n.stack != nil {
+		return fmt.Errorf("node %d is already running on port %d", n.index, n.port)
+	}
+	stack, err := node.New(&node.Config{
+		Name:              "devchain",
+		DataDir:           n.dataDir,
+		UseLightweightKDF: true,
+		HTTPHost:          "127.0.0.1",
+		HTTPPort:          n.port,
+		HTTPModules:       []string{"eth", "net", "web3"},
+		HTTPVirtualHosts:  []string{"*"},
+		WSHost:            "127.0.0.1",
+		WSPort:            n.port,
+		WSModules:         []string{"eth", "net", "web3"},
+		WSOrigins:         []string{"*"},
+		P2P: p2p.Config{
+			ListenAddr:  fmt.Sprintf("127.0.0.1:%d", n.p2pPort),
+			NoDiscovery: true,
+			// the eth handler syncs at once only with as many peers as it may have
+			MaxPeers: len(n.chain.nodes) - 1,
+		},
+	})
+	if err != nil {
+		return err
+	}
+	ethConfig := ethconfig.Defaults
+	ethConfig.Genesis = n.chain.genesis
+	ethConfig.NetworkId = n.chain.ChainID().Uint64()
+	ethConfig.SyncMode = downloader.FullSync
+	ethConfig.Miner.GasFloor = defaultGasLimit
+	ethConfig.Miner.GasCeil = defaultGasLimit
+	backend, err := eth.New(stack, &ethConfig)
+	if err != nil {
+		stack.Close()
+		return err
+	}
+	if err := stack.Start(); err != nil {
+		stack.Close()
+		return err
+	}
+	n.stack, n.backend = stack, backend
+	if err := n.bind(); err != nil {
+		_ = n.Stop()
+		return err
+	}
+	if n.index == 0 {
+		if err := n.seal(); err != nil {
+			_ = n.Stop()
+			return err
+		}
+	}
+
+	// trusted peers are let in whatever MaxPeers is
+	var peers int
+	for _, other := range n.chain.nodes {
+		if other != n && other.stack != nil {
+			other.stack.Server().AddTrustedPeer(stack.Server().Self())
+			stack.Server().AddTrustedPeer(other.stack.Server().Self())
+			stack.Server().AddPeer(other.stack.Server().Self())
+			peers++
+		}
+	}
+	return n.waitPeers(peers)
+}
+
+// bind notes the ports the node listens on, so that it is restarted on them
+func (n *Node) bind() error {
+	endpoint, err := url.Parse(n.stack.HTTPEndpoint())
+	if err != nil {
+		return err
+	}
+	if n.port, err = strconv.Atoi(endpoint.Port()); err != nil {
+		return err
+	}
+	n.p2pPort = n.stack.Server().NodeInfo().Ports.Listener
+	return nil
+}
+
+// seal makes the node seal the blocks with the key of the sealer
+func (n *Node) seal() error {
+	ks := n.stack.AccountManager().Backends(keystore.KeyStoreType)[0].(*keystore.KeyStore)
+	account := accounts.Account{Address: crypto.PubkeyToAddress(n.chain.sealer.PublicKey)}
+	if _, err := ks.ImportECDSA(n.chain.sealer, ""); err != nil && !errors.Is(err, keystore.ErrAccountAlreadyExists) {
+		return err
+	}
+	if err := ks.Unlock(account, ""); err != nil {
+		return err
+	}
+	n.backend.SetEtherbase(account.Address)
+	return n.backend.StartMining(1)
+}
+
+// waitPeers waits for the node to be connected over the eth protocol with count peers
+func (n *Node) waitPeers(count int) error {
+	return wait(func() bool {
+		connected := 0
+		for _, peer := range n.stack.Server().PeersInfo() {
+			if peer.Protocols["eth"] != nil {
+				connected++
+			}
+		}
+		return connected >= count
+	}, "node %d to connect with %d peers", n.index, count)
+}
+
+// waitBlock waits for the node to import the block of number, it returns at once if the node is stopped
+func (n *Node) waitBlock(number uint64) error {
+	if n.backend == nil {
+		return nil
+	}
+	return wait(func() bool {
+		return n.backend.BlockChain().CurrentBlock().NumberU64() >= number
+	}, "node %d to import block %d", n.index, number)
+}
+
+// Stop stops the node, requests to its endpoints are refused until it is started again
+func (n *Node) Stop() error {
+	if n.stack == nil {
+		return nil
+	}
+	err := n.stack.Close()
+	n.stack, n.backend = nil, nil
+	return err
+}
+
+func (n *Node) URL() string {
+	return fmt.Sprintf("http://%s", net.JoinHostPort("127.0.0.1", strconv.Itoa(n.port)))
+}
+
+func (n *Node) WSURL() string {
+	return fmt.Sprintf("ws://%s", net.JoinHostPort("127.0.0.1", strconv.Itoa(n.port)))
+}
+
+// wait polls done until it holds, or fails after waitTimeout
+func wait(done func() bool, format string, args ...interface{}) error {
+	ctx, cancel := context.WithTimeout(context.Background(), waitTimeout)
+	defer cancel()
+	ticker := time.NewTicker(10 * time.Millisecond)
+	defer ticker.Stop()
+	for !done() {
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("timed out waiting for "+format, args...)
+		case <-ticker.C:
+		}
+	}
+	return nil
+}
@@ -0,0 +1,108 @@
+package devchain
+
+import (
+	"context"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDevChain(t *testing.T) {
+	chain, err := Start(WithNodes(2), WithAccounts(1))
+	require.Nil(t, err)
+	defer chain.Close()
+	require.Equal(t, 2, len(chain.URLs()))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	key := chain.Accounts()[0]
+	from := crypto.PubkeyToAddress(key.PublicKey)
+
+	cli, err := ethclient.Dial(chain.URLs()[0])
+	require.Nil(t, err)
+	defer cli.Close()
+	chainID, err := cli.ChainID(ctx)
+	require.Nil(t, err)
+	balance, err := cli.BalanceAt(ctx, from, nil)
+	require.Nil(t, err)
+	require.Equal(t, defaultBalance, balance)
+
+	nonce, err := cli.NonceAt(ctx, from, nil)
+	require.Nil(t, err)
+	price, err := cli.SuggestGasPrice(ctx)
+	require.Nil(t, err)
+	tx, err := types.SignTx(types.NewTransaction(nonce, common.Address{0x1}, big.NewInt(1), 21000, price, nil), types.NewEIP155Signer(chainID), key)
+	require.Nil(t, err)
+	require.Nil(t, cli.SendTransaction(ctx, tx))
+
+	// the transaction is mined and visible from the other node, over websocket
+	wsCli, err := ethclient.Dial(chain.WSURLs()[1])
+	require.Nil(t, err)
+	defer wsCli.Close()
+	receipt, err := bind.WaitMined(ctx, wsCli, tx)
+	require.Nil(t, err)
+	assert.Equal(t, types.ReceiptStatusSuccessful, receipt.Status)
+	block, err := wsCli.BlockByNumber(ctx, receipt.BlockNumber)
+	require.Nil(t, err)
+	assert.Equal(t, uint64(1), block.NumberU64())
+	assert.Equal(t, tx.Hash(), block.Transactions()[0].Hash())
+	fetched, pending, err := wsCli.TransactionByHash(ctx, tx.Hash())
+	require.Nil(t, err)
+	assert.False(t, pending)
+	assert.Equal(t, tx.Hash(), fetched.Hash())
+}
+
+func TestDevChainRestartNode(t *testing.T) {
+	chain, err := Start()
+	require.Nil(t, err)
+	defer chain.Close()
+	url := chain.URLs()[0]
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	cli, err := ethclient.Dial(url)
+	require.Nil(t, err)
+	defer cli.Close()
+
+	require.Nil(t, chain.Node(0).Stop())
+	_, err = cli.ChainID(ctx)
+	require.NotNil(t, err)
+
+	require.Nil(t, chain.Node(0).Start())
+	require.Equal(t, url, chain.URLs()[0])
+	_, err = cli.ChainID(ctx)
+	require.Nil(t, err)
+}
+
+func TestDevChainMine(t *testing.T) {
+	chain, err := Start(WithNodes(2))
+	require.Nil(t, err)
+	defer chain.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	cli, err := ethclient.Dial(chain.URLs()[1])
+	require.Nil(t, err)
+	defer cli.Close()
+	require.Nil(t, chain.Mine())
+	number, err := cli.BlockNumber(ctx)
+	require.Nil(t, err)
+	assert.Equal(t, uint64(1), number)
+
+	// a restarted node syncs the blocks sealed while it was stopped
+	require.Nil(t, chain.Node(1).Stop())
+	require.Nil(t, chain.Mine())
+	require.Nil(t, chain.Node(1).Start())
+	require.Nil(t, chain.Node(1).waitBlock(2))
+	number, err = cli.BlockNumber(ctx)
+	require.Nil(t, err)
+	assert.Equal(t, uint64(2), number)
+}
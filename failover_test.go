@@ -0,0 +1,33 @@
+package go_eth_client
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/meshplus/go-eth-client/devchain"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFailover(t *testing.T) {
+	chain, err := devchain.Start(devchain.WithNodes(2), devchain.WithAccounts(1))
+	require.Nil(t, err)
+	defer chain.Close()
+	address := crypto.PubkeyToAddress(chain.Accounts()[0].PublicKey)
+
+	cli, err := New(WithUrls(chain.URLs()))
+	require.Nil(t, err)
+	defer cli.Stop()
+
+	require.Nil(t, chain.Node(0).Stop())
+	for i := 0; i < 4; i++ {
+		_, err := cli.EthGetBalance(address, nil)
+		require.Nil(t, err)
+	}
+
+	require.Nil(t, chain.Node(0).Start())
+	require.Nil(t, chain.Node(1).Stop())
+	for i := 0; i < 4; i++ {
+		_, err := cli.EthGetBalance(address, nil)
+		require.Nil(t, err)
+	}
+}
@@ -0,0 +1,250 @@
+// Package faultproxy provides a programmable JSON-RPC proxy for resilience tests.
+// The proxy forwards requests to a node and injects the faults scripted for the
+// called method: latency, dropped connections, HTTP errors, malformed responses,
+// stalls and stale results.
+package faultproxy
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// AnyMethod matches the requests of all methods
+const AnyMethod = "*"
+
+// Fault describes how a request is answered, the zero value forwards the request unchanged
+type Fault struct {
+	Latency    time.Duration // delay before the request is handled
+	Drop       bool          // close the connection without a response
+	StatusCode int           // respond with this http status, such as 503 or 429
+	Malformed  bool          // respond with a body that is not valid json
+	Stall      bool          // never respond until the client gives up or the proxy is closed
+	Stale      bool          // respond with the first result seen for the same request
+}
+
+// Proxy is a JSON-RPC proxy in front of a node
+type Proxy struct {
+	target   string
+	client   *http.Client
+	listener net.Listener
+	server   *http.Server
+	closed   chan struct{}
+	once     sync.Once
+
+	mu     sync.Mutex
+	rules  map[string][]rule
+	stale  map[string][]byte
+	counts map[string]int
+}
+
+type rule struct {
+	fault  Fault
+	always bool
+}
+
+type request struct {
+	Method string          `json:"method"`
+	Params json.RawMessage `json:"params"`
+}
+
+// New starts a proxy forwarding to the http endpoint target
+func New(target string) (*Proxy, error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, err
+	}
+	p := &Proxy{
+		target:   target,
+		client:   &http.Client{},
+		listener: listener,
+		closed:   make(chan struct{}),
+		rules:    make(map[string][]rule),
+		stale:    make(map[string][]byte),
+		counts:   make(map[string]int),
+	}
+	p.server = &http.Server{Handler: p}
+	go func() {
+		_ = p.server.Serve(listener)
+	}()
+	return p, nil
+}
+
+// URL returns the endpoint of the proxy
+func (p *Proxy) URL() string {
+	return "http://" + p.listener.Addr().String()
+}
+
+// On scripts the faults for the following requests of method, one fault per request
+// in order. Requests after the script has been used up are forwarded unchanged.
+func (p *Proxy) On(method string, faults ...Fault) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for _, fault := range faults {
+		p.rules[method] = append(p.rules[method], rule{fault: fault})
+	}
+}
+
+// Always applies fault to every following request of method until Reset
+func (p *Proxy) Always(method string, fault Fault) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.rules[method] = append(p.rules[method], rule{fault: fault, always: true})
+}
+
+// Reset drops all scripted faults and recorded results
+func (p *Proxy) Reset() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.rules = make(map[string][]rule)
+	p.stale = make(map[string][]byte)
+}
+
+// Count returns the number of requests received for method
+func (p *Proxy) Count(method string) int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.counts[method]
+}
+
+// Close stops the proxy and releases the stalled requests, it may be called more than once
+func (p *Proxy) Close() error {
+	p.once.Do(func() {
+		close(p.closed)
+	})
+	return p.server.Close()
+}
+
+func (p *Proxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	var req request
+	// batch requests are forwarded without faults
+	_ = json.Unmarshal(body, &req)
+	fault := p.next(req.Method)
+
+	if fault.Latency > 0 {
+		select {
+		case <-time.After(fault.Latency):
+		case <-r.Context().Done():
+			return
+		case <-p.closed:
+			return
+		}
+	}
+	switch {
+	case fault.Stall:
+		select {
+		case <-r.Context().Done():
+		case <-p.closed:
+		}
+		return
+	case fault.Drop:
+		hijacker, ok := w.(http.Hijacker)
+		if !ok {
+			http.Error(w, "drop is not supported", http.StatusInternalServerError)
+			return
+		}
+		conn, _, err := hijacker.Hijack()
+		if err == nil {
+			_ = conn.Close()
+		}
+		return
+	case fault.StatusCode != 0:
+		http.Error(w, http.StatusText(fault.StatusCode), fault.StatusCode)
+		return
+	case fault.Malformed:
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"jsonrpc":"2.0","id":1,"result":`))
+		return
+	}
+
+	key := req.Method + string(req.Params)
+	if fault.Stale {
+		p.mu.Lock()
+		result, ok := p.stale[key]
+		p.mu.Unlock()
+		if ok {
+			writeResult(w, body, result)
+			return
+		}
+	}
+
+	resp, err := p.forward(r, body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+	// remember the first result of every request to serve it as stale data later
+	var msg struct {
+		Result json.RawMessage `json:"result"`
+	}
+	if err := json.Unmarshal(resp, &msg); err == nil && msg.Result != nil {
+		p.mu.Lock()
+		if _, ok := p.stale[key]; !ok {
+			p.stale[key] = msg.Result
+		}
+		p.mu.Unlock()
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(resp)
+}
+
+// next returns the fault for the next request of method
+func (p *Proxy) next(method string) Fault {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.counts[method]++
+	for _, m := range []string{method, AnyMethod} {
+		rules := p.rules[m]
+		if len(rules) == 0 {
+			continue
+		}
+		if !rules[0].always {
+			p.rules[m] = rules[1:]
+		}
+		return rules[0].fault
+	}
+	return Fault{}
+}
+
+func (p *Proxy) forward(r *http.Request, body []byte) ([]byte, error) {
+	req, err := http.NewRequestWithContext(r.Context(), http.MethodPost, p.target, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header = r.Header.Clone()
+	// let the transport negotiate and decode the compression, the body is parsed here
+	req.Header.Del("Accept-Encoding")
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("target responded %s", resp.Status)
+	}
+	return ioutil.ReadAll(resp.Body)
+}
+
+// writeResult answers the request in body with result
+func writeResult(w http.ResponseWriter, body []byte, result json.RawMessage) {
+	var req struct {
+		Id json.RawMessage `json:"id"`
+	}
+	_ = json.Unmarshal(body, &req)
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{
+		"jsonrpc": "2.0",
+		"id":      req.Id,
+		"result":  result,
+	})
+}
@@ -0,0 +1,99 @@
+package faultproxy
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/meshplus/go-eth-client/devchain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newProxy(t *testing.T) (*devchain.DevChain, *Proxy, *ethclient.Client) {
+	chain, err := devchain.Start()
+	require.Nil(t, err)
+	proxy, err := New(chain.URLs()[0])
+	require.Nil(t, err)
+	cli, err := ethclient.Dial(proxy.URL())
+	require.Nil(t, err)
+	return chain, proxy, cli
+}
+
+func TestProxyFaults(t *testing.T) {
+	chain, proxy, cli := newProxy(t)
+	defer chain.Close()
+	defer proxy.Close()
+	defer cli.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	proxy.On("eth_chainId",
+		Fault{Latency: 100 * time.Millisecond},
+		Fault{Drop: true},
+		Fault{StatusCode: 503},
+		Fault{StatusCode: 429},
+		Fault{Malformed: true},
+	)
+	start := time.Now()
+	_, err := cli.ChainID(ctx)
+	require.Nil(t, err)
+	assert.True(t, time.Since(start) >= 100*time.Millisecond)
+	_, err = cli.ChainID(ctx)
+	assert.NotNil(t, err)
+	_, err = cli.ChainID(ctx)
+	assert.Contains(t, err.Error(), "503")
+	_, err = cli.ChainID(ctx)
+	assert.Contains(t, err.Error(), "429")
+	_, err = cli.ChainID(ctx)
+	assert.NotNil(t, err)
+	_, err = cli.ChainID(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, 6, proxy.Count("eth_chainId"))
+}
+
+func TestProxyStall(t *testing.T) {
+	chain, proxy, cli := newProxy(t)
+	defer chain.Close()
+	defer proxy.Close()
+	defer cli.Close()
+
+	proxy.Always(AnyMethod, Fault{Stall: true})
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	_, err := cli.BlockNumber(ctx)
+	require.NotNil(t, err)
+
+	proxy.Reset()
+	_, err = cli.BlockNumber(context.Background())
+	require.Nil(t, err)
+}
+
+func TestProxyStale(t *testing.T) {
+	chain, proxy, cli := newProxy(t)
+	defer chain.Close()
+	defer proxy.Close()
+	defer cli.Close()
+
+	number, err := cli.BlockNumber(context.Background())
+	require.Nil(t, err)
+	require.Nil(t, chain.Mine())
+
+	proxy.On("eth_blockNumber", Fault{Stale: true})
+	stale, err := cli.BlockNumber(context.Background())
+	require.Nil(t, err)
+	assert.Equal(t, number, stale)
+	latest, err := cli.BlockNumber(context.Background())
+	require.Nil(t, err)
+	assert.Equal(t, number+1, latest)
+}
+
+func TestProxyClose(t *testing.T) {
+	proxy, err := New("http://127.0.0.1:1")
+	require.Nil(t, err)
+	require.Nil(t, proxy.Close())
+	require.NotPanics(t, func() {
+		_ = proxy.Close()
+	})
+}
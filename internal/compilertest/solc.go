@@ -0,0 +1,216 @@
+// Package compilertest provides a stand-in for solc, so that code driving solc can be
+// tested where no solc is installed.
+//
+// The stand-in is the test binary itself running the test TestSolcStandIn, which the test
+// package declares in a _test.go file as:
+//
+//	func TestSolcStandIn(t *testing.T) {
+//		compilertest.Main()
+//	}
+//
+// For every contract, interface and library declared in the sources, the stand-in returns
+// the solc output of testdata/storage.sol, interfaces and abstract contracts get empty
+// bytecode. The ast of a source unit only holds the contract definitions. A line containing
+// "// error: message" or "// warning: message" is reported as an error or a warning at the
+// position of the comment.
+package compilertest
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const (
+	envSolc    = "COMPILERTEST_SOLC"
+	envLog     = "COMPILERTEST_SOLC_LOG"
+	envVersion = "COMPILERTEST_SOLC_VERSION"
+
+	// DefaultVersion is the version reported by the stand-in
+	DefaultVersion = "0.8.19+commit.7dd6d404.Linux.g++"
+
+	// standInTest is the test of the test binary running the stand-in
+	standInTest = "TestSolcStandIn"
+)
+
+var (
+	//go:embed storage.json
+	storageOutput []byte
+
+	contractRegexp = regexp.MustCompile(`(?m)^\s*(abstract\s+)?(contract|interface|library)\s+(\w+)`)
+	messageRegexp  = regexp.MustCompile(`//\s*(error|warning):\s*(.*)`)
+)
+
+// Solc is a stand-in for solc
+type Solc struct {
+	Path string // path of the executable
+	log  string
+}
+
+// Call is a run of the stand-in
+type Call struct {
+	Args  []string        `json:"args"`
+	Input json.RawMessage `json:"input"`
+}
+
+// TB is the part of testing.TB the stand-in uses
+type TB interface {
+	Helper()
+	Fatal(args ...interface{})
+	TempDir() string
+}
+
+// NewSolc writes an executable which runs the test binary as solc of version, DefaultVersion if it is empty
+func NewSolc(t TB, version string) *Solc {
+	t.Helper()
+	if version == "" {
+		version = DefaultVersion
+	}
+	dir := t.TempDir()
+	solc := &Solc{Path: filepath.Join(dir, "solc"), log: filepath.Join(dir, "calls.jsonl")}
+	script := fmt.Sprintf("#!/bin/sh\nexec env %s=1 %s=%s %s=%s %s -test.run=%s -- \"$@\"\n",
+		envSolc, envLog, strconv.Quote(solc.log), envVersion, strconv.Quote(version), strconv.Quote(os.Args[0]),
+		strconv.Quote("^"+standInTest+"$"))
+	if err := ioutil.WriteFile(solc.Path, []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	return solc
+}
+
+// Calls returns the standard-json runs of the stand-in
+func (s *Solc) Calls(t TB) []Call {
+	t.Helper()
+	data, err := ioutil.ReadFile(s.log)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	var calls []Call
+	for _, line := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+		var call Call
+		if err := json.Unmarshal([]byte(line), &call); err != nil {
+			t.Fatal(err)
+		}
+		calls = append(calls, call)
+	}
+	return calls
+}
+
+// Main runs the stand-in and exits if the test binary is started as solc, it returns otherwise
+func Main() {
+	if os.Getenv(envSolc) == "" {
+		return
+	}
+	var args []string
+	for i, arg := range os.Args {
+		if arg == "--" {
+			args = os.Args[i+1:]
+			break
+		}
+	}
+	if err := run(args); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	os.Exit(0)
+}
+
+func run(args []string) error {
+	if len(args) > 0 && args[0] == "--version" {
+		fmt.Printf("solc, the solidity compiler commandline interface\nVersion: %s\n", os.Getenv(envVersion))
+		return nil
+	}
+	if len(args) == 0 || args[0] != "--standard-json" {
+		return fmt.Errorf("only --version and --standard-json are supported")
+	}
+	data, err := ioutil.ReadAll(os.Stdin)
+	if err != nil {
+		return err
+	}
+	if err := writeLog(Call{Args: args, Input: data}); err != nil {
+		return err
+	}
+	var input struct {
+		Sources map[string]struct {
+			Content string `json:"content"`
+		} `json:"sources"`
+	}
+	if err := json.Unmarshal(data, &input); err != nil {
+		return err
+	}
+
+	var errors []interface{}
+	sources := make(map[string]interface{})
+	contracts := make(map[string]map[string]interface{})
+	id := 0
+	for name, source := range input.Sources {
+		var nodes []interface{}
+		ast := map[string]interface{}{"absolutePath": name, "id": id, "nodeType": "SourceUnit"}
+		sources[name] = map[string]interface{}{"id": id, "ast": ast}
+		id++
+		contracts[name] = make(map[string]interface{})
+		for _, match := range contractRegexp.FindAllStringSubmatch(source.Content, -1) {
+			nodes = append(nodes, map[string]interface{}{
+				"nodeType":     "ContractDefinition",
+				"name":         match[3],
+				"contractKind": match[2],
+				"abstract":     match[1] != "",
+			})
+			var contract map[string]interface{}
+			if err := json.Unmarshal(storageOutput, &contract); err != nil {
+				return err
+			}
+			if match[1] != "" || match[2] == "interface" {
+				evm := contract["evm"].(map[string]interface{})
+				evm["bytecode"].(map[string]interface{})["object"] = ""
+				evm["deployedBytecode"].(map[string]interface{})["object"] = ""
+			}
+			contracts[name][match[3]] = contract
+		}
+		ast["nodes"] = nodes
+		for _, loc := range messageRegexp.FindAllStringSubmatchIndex(source.Content, -1) {
+			severity, message := source.Content[loc[2]:loc[3]], source.Content[loc[4]:loc[5]]
+			line := strings.Count(source.Content[:loc[0]], "\n") + 1
+			column := loc[0] - strings.LastIndex(source.Content[:loc[0]], "\n")
+			typ := "Warning"
+			if severity == "error" {
+				typ = "ParserError"
+			}
+			errors = append(errors, map[string]interface{}{
+				"severity":         severity,
+				"type":             typ,
+				"component":        "general",
+				"message":          message,
+				"formattedMessage": fmt.Sprintf("%s: %s\n --> %s:%d:%d:\n", typ, message, name, line, column),
+				"sourceLocation":   map[string]interface{}{"file": name, "start": loc[0], "end": loc[1]},
+			})
+		}
+	}
+	return json.NewEncoder(os.Stdout).Encode(map[string]interface{}{
+		"errors":    errors,
+		"sources":   sources,
+		"contracts": contracts,
+	})
+}
+
+func writeLog(call Call) error {
+	data, err := json.Marshal(call)
+	if err != nil {
+		return err
+	}
+	file, err := os.OpenFile(os.Getenv(envLog), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	_, err = file.Write(append(data, '\n'))
+	return err
+}
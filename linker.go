@@ -0,0 +1,272 @@
+package go_eth_client
+
+import (
+	"crypto/ecdsa"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/meshplus/go-eth-client/compiler"
+)
+
+// libraryReference is a library a bytecode is linked with
+type libraryReference struct {
+	source string // source of the library, empty if it is only known from the placeholder
+	name   string // name of the library, or the placeholder without the underscores
+}
+
+func (r libraryReference) String() string {
+	if r.source == "" {
+		return r.name
+	}
+	return r.source + ":" + r.name
+}
+
+// matches reports whether the library named key, by the fully qualified name or the library name, is the referenced one
+func (r libraryReference) matches(key string) bool {
+	if key == r.String() || key == r.name {
+		return true
+	}
+	if r.source != "" {
+		return false
+	}
+	// __$<hash>$__ of solc >= 0.5.0, or __<file.sol:Library>__ of older versions
+	if strings.HasPrefix(r.name, "$") {
+		return r.name == "$"+LibraryPlaceholder(key)[3:37]+"$"
+	}
+	return strings.HasSuffix(key, ":"+r.name) || (len(r.name) == 36 && strings.HasPrefix(key, r.name))
+}
+
+// LibraryPlaceholder returns the placeholder solc >= 0.5.0 writes for the library named by the fully qualified name,
+// such bytecodes without link references can only be linked with libraries keyed by the fully qualified names
+func LibraryPlaceholder(id string) string {
+	return "__$" + hex.EncodeToString(crypto.Keccak256([]byte(id)))[:34] + "$__"
+}
+
+// Libraries returns the libraries the artifact has to be linked with, by the fully qualified names if the
+// sources are known, or by the placeholders otherwise
+func (a *Artifact) Libraries() []string {
+	var names []string
+	for _, reference := range a.libraryReferences() {
+		names = append(names, reference.String())
+	}
+	return names
+}
+
+func (a *Artifact) libraryReferences() []libraryReference {
+	seen := make(map[libraryReference]bool)
+	var references []libraryReference
+	for _, linkReferences := range []map[string]map[string][]compiler.Offset{a.LinkReferences, a.DeployedLinkReferences} {
+		for source, libraries := range linkReferences {
+			for name := range libraries {
+				reference := libraryReference{source: source, name: name}
+				if !seen[reference] {
+					seen[reference] = true
+					references = append(references, reference)
+				}
+			}
+		}
+	}
+	sort.Slice(references, func(i, j int) bool {
+		return references[i].String() < references[j].String()
+	})
+	return references
+}
+
+// Link returns a copy of the artifact whose library placeholders are replaced by the addresses of libraries,
+// keyed by the fully qualified names or the library names. Every library referenced has to be given.
+func (a *Artifact) Link(libraries map[string]common.Address) (*Artifact, error) {
+	linked := *a
+	var err error
+	if linked.Bytecode, err = link(a.Id(), a.Bytecode, a.LinkReferences, libraries); err != nil {
+		return nil, err
+	}
+	if linked.DeployedBytecode, err = link(a.Id(), a.DeployedBytecode, a.DeployedLinkReferences, libraries); err != nil {
+		return nil, err
+	}
+	linked.LinkReferences, linked.DeployedLinkReferences = nil, nil
+	return &linked, nil
+}
+
+// link writes the addresses of the libraries into bytecode at the offsets of references
+func link(id, bytecode string, references map[string]map[string][]compiler.Offset, libraries map[string]common.Address) (string, error) {
+	code := []byte(bytecode)
+	prefix := 0
+	if strings.HasPrefix(bytecode, "0x") {
+		prefix = 2
+	}
+	for source, names := range references {
+		for name, offsets := range names {
+			reference := libraryReference{source: source, name: name}
+			key, ok := libraryKey(reference, libraries)
+			if !ok {
+				return "", fmt.Errorf("library %s of %s is not linked", reference, id)
+			}
+			address := libraries[key]
+			for _, offset := range offsets {
+				start, end := prefix+offset.Start*2, prefix+(offset.Start+offset.Length)*2
+				if offset.Length != common.AddressLength || end > len(code) {
+					return "", fmt.Errorf("invalid link reference of %s in %s", reference, id)
+				}
+				hex.Encode(code[start:end], address.Bytes())
+			}
+		}
+	}
+	if err := checkLinked(string(code)); err != nil {
+		return "", fmt.Errorf("link %s: %w", id, err)
+	}
+	return string(code), nil
+}
+
+// libraryKey returns the key of the referenced library in libraries, preferring the fully qualified name
+func libraryKey(reference libraryReference, libraries map[string]common.Address) (string, bool) {
+	if _, ok := libraries[reference.String()]; ok {
+		return reference.String(), true
+	}
+	keys := make([]string, 0, len(libraries))
+	for key := range libraries {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if reference.matches(key) {
+			return key, true
+		}
+	}
+	return "", false
+}
+
+// checkLinked returns an error if code still holds library placeholders
+func checkLinked(code string) error {
+	if references := findLinkReferences(code); references != nil {
+		var names []string
+		for name := range references[""] {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return fmt.Errorf("bytecode is not linked with libraries %s", strings.Join(names, ", "))
+	}
+	return nil
+}
+
+// DeployWithLibraries deploys the contract of result named name with args. The libraries it is linked with are
+// taken from libraries, keyed by the fully qualified names or the library names, and those not given are deployed
+// from result first, with the libraries they are linked with. The addresses of the contract and of all the
+// libraries it is linked with are returned.
+func (rpc *EthRPC) DeployWithLibraries(privKey *ecdsa.PrivateKey, result *CompileResult, name string, args []interface{},
+	libraries map[string]string, opts ...TransactionOption) (map[string]string, error) {
+
+	artifacts := result.artifacts()
+	artifact, err := artifacts.Get(name)
+	if err != nil {
+		return nil, err
+	}
+	deployed := make(map[string]common.Address, len(libraries))
+	for key, address := range libraries {
+		if !common.IsHexAddress(address) {
+			return nil, fmt.Errorf("invalid address %s of library %s", address, key)
+		}
+		deployed[key] = common.HexToAddress(address)
+	}
+	l := &linker{rpc: rpc, privKey: privKey, opts: opts, artifacts: artifacts, deployed: deployed}
+	address, err := l.deploy(artifact, args)
+	if err != nil {
+		return nil, err
+	}
+	addresses := map[string]string{artifact.Id(): address.String()}
+	for _, id := range l.linked {
+		addresses[id] = l.deployed[id].String()
+	}
+	return addresses, nil
+}
+
+// linker deploys contracts after the libraries they are linked with
+type linker struct {
+	rpc       *EthRPC
+	privKey   *ecdsa.PrivateKey
+	opts      []TransactionOption
+	artifacts Artifacts
+	deployed  map[string]common.Address // 已部署的库及合约，名称 -> 地址
+	linked    []string                  // 部署过程中链接的库
+	deploying map[string]bool
+}
+
+// deploy links the artifact, deploying the libraries missing, and deploys it
+func (l *linker) deploy(artifact *Artifact, args []interface{}) (common.Address, error) {
+	if !artifact.Deployable() {
+		return common.Address{}, fmt.Errorf("contract %s is abstract or an interface", artifact.Id())
+	}
+	if l.deploying == nil {
+		l.deploying = make(map[string]bool)
+	}
+	if l.deploying[artifact.Id()] {
+		return common.Address{}, fmt.Errorf("libraries of %s are linked in a cycle", artifact.Id())
+	}
+	l.deploying[artifact.Id()] = true
+	defer delete(l.deploying, artifact.Id())
+
+	for _, reference := range artifact.libraryReferences() {
+		key, ok := libraryKey(reference, l.deployed)
+		if !ok {
+			library, err := l.library(reference)
+			if err != nil {
+				return common.Address{}, fmt.Errorf("link %s: %w", artifact.Id(), err)
+			}
+			if _, err := l.deploy(library, nil); err != nil {
+				return common.Address{}, err
+			}
+			key = library.Id()
+		}
+		l.record(key)
+	}
+	linked, err := artifact.Link(l.deployed)
+	if err != nil {
+		return common.Address{}, err
+	}
+	parsed, err := abi.JSON(strings.NewReader(string(linked.Abi)))
+	if err != nil {
+		return common.Address{}, fmt.Errorf("parse abi of %s: %w", linked.Id(), err)
+	}
+	res, err := l.rpc.deployCode(linked.Id(), l.privKey, parsed, linked.Bytecode, args, l.opts...)
+	if err != nil {
+		return common.Address{}, fmt.Errorf("deploy %s: %w", linked.Id(), err)
+	}
+	l.deployed[linked.Id()] = res.Address
+	l.opts = nextNonce(l.opts, res.Nonce)
+	return res.Address, nil
+}
+
+// library returns the artifact of the referenced library
+func (l *linker) library(reference libraryReference) (*Artifact, error) {
+	if library, ok := l.artifacts[reference.String()]; ok {
+		return library, nil
+	}
+	var found []*Artifact
+	for _, id := range l.artifacts.Ids() {
+		if reference.matches(id) {
+			found = append(found, l.artifacts[id])
+		}
+	}
+	switch len(found) {
+	case 0:
+		return nil, fmt.Errorf("library %s is neither given nor found", reference)
+	case 1:
+		return found[0], nil
+	default:
+		return nil, fmt.Errorf("library %s is ambiguous, give its address", reference)
+	}
+}
+
+// record notes that the library key is linked, once
+func (l *linker) record(key string) {
+	for _, linked := range l.linked {
+		if linked == key {
+			return
+		}
+	}
+	l.linked = append(l.linked, key)
+}
@@ -0,0 +1,134 @@
+package go_eth_client
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLibraryPlaceholder(t *testing.T) {
+	assert.Equal(t, mathPlaceholder, LibraryPlaceholder("contracts/Math.sol:Math"))
+	assert.Equal(t, "__$22ef75b31e2d998cd01172b890884772a9$__", LibraryPlaceholder("src/Math.sol:Math"))
+}
+
+func TestArtifactLink(t *testing.T) {
+	library := common.HexToAddress("0x00000000000000000000000000000000000000AA")
+	libraryHex := strings.ToLower(library.Hex()[2:])
+
+	token, err := LoadArtifact("./testdata/artifacts/hardhat/artifacts/contracts/Token.sol/Token.json")
+	require.Nil(t, err)
+	assert.Equal(t, []string{"contracts/Math.sol:Math"}, token.Libraries())
+	linked, err := token.Link(map[string]common.Address{"Math": library})
+	require.Nil(t, err)
+	assert.Equal(t, libraryHex, linked.Bytecode[2+12*2:2+32*2])
+	assert.Equal(t, libraryHex, linked.DeployedBytecode[2+1*2:2+21*2])
+	assert.Equal(t, len(token.Bytecode), len(linked.Bytecode))
+	assert.Empty(t, linked.Libraries())
+	// the artifact itself is kept unlinked
+	assert.Contains(t, token.Bytecode, mathPlaceholder)
+	_, err = token.Link(map[string]common.Address{"src/Math.sol:Math": library})
+	require.NotNil(t, err)
+
+	truffle, err := LoadArtifact("./testdata/artifacts/truffle/build/contracts/Token.json")
+	require.Nil(t, err)
+	assert.Equal(t, []string{"Math"}, truffle.Libraries())
+	linked, err = truffle.Link(map[string]common.Address{"contracts/Math.sol:Math": library})
+	require.Nil(t, err)
+	assert.Equal(t, libraryHex, linked.Bytecode[2+12*2:2+32*2])
+
+	// only the hash of the fully qualified name is known from the placeholder
+	bin, err := LoadAbiBin("./testdata/artifacts/abibin/Token.abi", "./testdata/artifacts/abibin/Token.bin")
+	require.Nil(t, err)
+	_, err = bin.Link(map[string]common.Address{"Math": library})
+	require.NotNil(t, err)
+	linked, err = bin.Link(map[string]common.Address{"contracts/Math.sol:Math": library})
+	require.Nil(t, err)
+	require.Nil(t, checkLinked(linked.Bytecode))
+	require.NotNil(t, checkLinked(bin.Bytecode))
+}
+
+func TestSimulatedDeployWithLibraries(t *testing.T) {
+	sim, _ := newSimulated(t)
+	defer sim.Stop()
+
+	artifacts, err := LoadArtifacts("./testdata/artifacts/hardhat/artifacts")
+	require.Nil(t, err)
+	result := NewCompileResult(artifacts...)
+	tokenAbi, err := abi.JSON(strings.NewReader(result.Abi[2]))
+	require.Nil(t, err)
+
+	// Math is deployed, Token can't be without linking
+	deployed, err := sim.DeployWithReceipt(nil, result, nil)
+	require.NotNil(t, err)
+	assert.Contains(t, err.Error(), "contracts/Token.sol:Token")
+	var deploymentErr *DeploymentError
+	require.True(t, errors.As(err, &deploymentErr))
+	require.Equal(t, 2, len(deployed))
+	assert.Equal(t, deploymentErr.Results[0].Address.String(), deployed[0])
+	assert.NotEqual(t, common.Address{}.String(), deployed[0])
+	assert.Equal(t, common.Address{}.String(), deployed[1])
+
+	addresses, err := sim.DeployWithLibraries(nil, result, "Token", nil, nil)
+	require.Nil(t, err)
+	require.Equal(t, 2, len(addresses))
+	math := addresses["contracts/Math.sol:Math"]
+	code, err := sim.EthGetCode(common.HexToAddress(math), nil)
+	require.Nil(t, err)
+	require.Equal(t, "00", code)
+	res, err := sim.EthCall(&tokenAbi, addresses["contracts/Token.sol:Token"], "math", nil)
+	require.Nil(t, err)
+	require.Equal(t, common.HexToAddress(math), res[0])
+
+	// an existing library is linked instead of deployed
+	existing, err := sim.DeployWithLibraries(nil, result, "contracts/Token.sol:Token", nil, map[string]string{"Math": math})
+	require.Nil(t, err)
+	require.Equal(t, map[string]string{"contracts/Token.sol:Token": existing["contracts/Token.sol:Token"], "Math": math}, existing)
+	res, err = sim.EthCall(&tokenAbi, existing["contracts/Token.sol:Token"], "math", nil)
+	require.Nil(t, err)
+	require.Equal(t, common.HexToAddress(math), res[0])
+
+	// a library selected along with the contract linked with it is deployed once
+	contracts, err := sim.DeployContracts(nil, result, map[string][]interface{}{"Token": nil, "Math": nil})
+	require.Nil(t, err)
+	require.Equal(t, 2, len(contracts))
+	res, err = sim.EthCall(&tokenAbi, contracts["contracts/Token.sol:Token"], "math", nil)
+	require.Nil(t, err)
+	require.Equal(t, common.HexToAddress(contracts["contracts/Math.sol:Math"]), res[0])
+
+	// the contract is deployed with the nonce following the library's
+	from := crypto.PubkeyToAddress(sim.privateKey.PublicKey)
+	nonce, err := sim.EthGetTransactionCount(from, nil)
+	require.Nil(t, err)
+	addresses, err = sim.DeployWithLibraries(nil, result, "Token", nil, nil, WithNonce(nonce))
+	require.Nil(t, err)
+	require.Equal(t, 2, len(addresses))
+	require.Equal(t, PredictAddress(from, nonce).String(), addresses["contracts/Math.sol:Math"])
+	require.Equal(t, PredictAddress(from, nonce+1).String(), addresses["contracts/Token.sol:Token"])
+
+	_, err = sim.DeployWithLibraries(nil, NewCompileResult(artifacts[2]), "Token", nil, nil)
+	require.NotNil(t, err)
+	_, err = sim.DeployWithLibraries(nil, result, "Token", nil, map[string]string{"Math": "0x1"})
+	require.NotNil(t, err)
+}
+
+func TestSimulatedDeployTruffleWithLibraries(t *testing.T) {
+	sim, _ := newSimulated(t)
+	defer sim.Stop()
+
+	artifacts, err := LoadArtifacts("./testdata/artifacts/truffle/build")
+	require.Nil(t, err)
+	addresses, err := sim.DeployWithLibraries(nil, NewCompileResult(artifacts...), "Token", nil, nil)
+	require.Nil(t, err)
+	require.Equal(t, 2, len(addresses))
+	tokenAbi, err := abi.JSON(strings.NewReader(string(artifacts[1].Abi)))
+	require.Nil(t, err)
+	res, err := sim.EthCall(&tokenAbi, addresses["contracts/Token.sol:Token"], "math", nil)
+	require.Nil(t, err)
+	require.Equal(t, common.HexToAddress(addresses["contracts/Math.sol:Math"]), res[0])
+}
@@ -0,0 +1,300 @@
+package go_eth_client
+
+import (
+	"crypto/ecdsa"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+const (
+	manifestLockTimeout = 10 * time.Second      // 等待其他客户端释放部署清单锁的最长时间
+	manifestLockRetry   = 10 * time.Millisecond // 重试获取部署清单锁的间隔
+)
+
+// ManifestEntry is a deployment recorded in a manifest
+type ManifestEntry struct {
+	Name         string        `json:"name"`           // 合约名，未指定时为合约地址
+	Address      string        `json:"address"`        // 合约地址
+	TxHash       string        `json:"txHash"`         // 部署交易哈希
+	BlockNumber  uint64        `json:"blockNumber"`    // 部署交易所在区块
+	InitCodeHash string        `json:"initCodeHash"`   // 部署字节码及构造参数的keccak256哈希
+	BytecodeHash string        `json:"bytecodeHash"`   // 链上运行时字节码的keccak256哈希
+	Args         []interface{} `json:"args,omitempty"` // 构造参数
+}
+
+// Manifest records the contracts deployed to a chain by name, it is stored as <chain id>.json in its directory
+type Manifest struct {
+	ChainId   string                    `json:"chainId"`
+	Contracts map[string]*ManifestEntry `json:"contracts"`
+
+	path string
+	mu   sync.Mutex
+}
+
+// LoadManifest loads the manifest of the chain from dir, the manifest is empty if the file doesn't exist
+func LoadManifest(dir string, chainId *big.Int) (*Manifest, error) {
+	if chainId == nil {
+		return nil, fmt.Errorf("load manifest: unknown chain id")
+	}
+	manifest := &Manifest{
+		ChainId:   chainId.String(),
+		Contracts: make(map[string]*ManifestEntry),
+		path:      filepath.Join(dir, chainId.String()+".json"),
+	}
+	if err := manifest.read(); err != nil {
+		return nil, err
+	}
+	return manifest, nil
+}
+
+// read merges the entries stored in the file into the manifest, they replace the entries of the same names
+func (m *Manifest) read() error {
+	data, err := ioutil.ReadFile(m.path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("load manifest: %w", err)
+	}
+	var stored struct {
+		ChainId   string                    `json:"chainId"`
+		Contracts map[string]*ManifestEntry `json:"contracts"`
+	}
+	if err := json.Unmarshal(data, &stored); err != nil {
+		return fmt.Errorf("decode manifest %s: %w", m.path, err)
+	}
+	if stored.ChainId != m.ChainId {
+		return fmt.Errorf("manifest %s is of chain %s", m.path, stored.ChainId)
+	}
+	for name, entry := range stored.Contracts {
+		m.Contracts[name] = entry
+	}
+	return nil
+}
+
+// Path returns the file the manifest is stored in
+func (m *Manifest) Path() string {
+	return m.path
+}
+
+// Get returns the entry recorded by name
+func (m *Manifest) Get(name string) (*ManifestEntry, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	entry, ok := m.Contracts[name]
+	return entry, ok
+}
+
+// Names returns the sorted names of the entries
+func (m *Manifest) Names() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	names := make([]string, 0, len(m.Contracts))
+	for name := range m.Contracts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Record records entry by its name, replacing the earlier one, and saves the manifest. The file is read
+// again under a lock file first, so that the entries recorded by other clients sharing it are kept.
+func (m *Manifest) Record(entry *ManifestEntry) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	unlock, err := m.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	if err := m.read(); err != nil {
+		return err
+	}
+	m.Contracts[entry.Name] = entry
+	return m.save()
+}
+
+// lock creates the lock file of the manifest, waiting for the client holding it, and returns the function
+// removing it. A lock file left by a crashed client has to be removed by hand.
+func (m *Manifest) lock() (func(), error) {
+	if err := os.MkdirAll(filepath.Dir(m.path), 0755); err != nil {
+		return nil, fmt.Errorf("lock manifest: %w", err)
+	}
+	path := m.path + ".lock"
+	deadline := time.Now().Add(manifestLockTimeout)
+	for {
+		file, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+		if err == nil {
+			file.Close()
+			return func() {
+				_ = os.Remove(path)
+			}, nil
+		}
+		if !os.IsExist(err) {
+			return nil, fmt.Errorf("lock manifest: %w", err)
+		}
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("lock manifest: %s is held by another client, remove it if none is running", path)
+		}
+		time.Sleep(manifestLockRetry)
+	}
+}
+
+// save writes the manifest to a temporary file first so that it is never left partially written
+func (m *Manifest) save() error {
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encode manifest: %w", err)
+	}
+	dir := filepath.Dir(m.path)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("write manifest: %w", err)
+	}
+	tmp, err := ioutil.TempFile(dir, filepath.Base(m.path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("write manifest: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("write manifest: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("write manifest: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), m.path); err != nil {
+		return fmt.Errorf("write manifest: %w", err)
+	}
+	return nil
+}
+
+// Manifest returns the manifest of the chain set by WithManifestDir, it is nil if deployments are not recorded
+func (rpc *EthRPC) Manifest() *Manifest {
+	return rpc.manifest
+}
+
+// record records a successful deployment to the manifest, if there is one. Deployments without receipts are
+// not recorded as they may never be mined. The deployment is done already, so a failure to record it is only
+// logged.
+func (rpc *EthRPC) record(name string, res *DeploymentResult, initCode []byte, args []interface{}) {
+	if rpc.manifest == nil || res.Err != nil || !res.Mined() {
+		return
+	}
+	if name == "" {
+		name = res.Address.String()
+	}
+	entry := &ManifestEntry{
+		Name:         name,
+		Address:      res.Address.String(),
+		TxHash:       res.TxHash.String(),
+		BlockNumber:  res.BlockNumber,
+		InitCodeHash: crypto.Keccak256Hash(initCode).String(),
+		Args:         args,
+	}
+	code, err := rpc.EthGetCode(res.Address, nil)
+	if err != nil {
+		rpc.logger.Warningf("Record deployment of %s: %s", name, err)
+		return
+	}
+	entry.BytecodeHash = crypto.Keccak256Hash(common.FromHex(code)).String()
+	if err := rpc.manifest.Record(entry); err != nil {
+		rpc.logger.Warningf("Record deployment of %s: %s", name, err)
+	}
+}
+
+// Migration is a step of Migrate deploying a contract
+type Migration struct {
+	Name string  // 合约名，即部署清单中的名称
+	Abi  abi.ABI // 合约ABI
+	Code string  // 部署字节码
+	// Args returns the constructor arguments, it may refer to the addresses of the contracts deployed by the
+	// earlier steps or recorded in the manifest, by name. No arguments are given if it is nil.
+	Args func(addresses map[string]common.Address) ([]interface{}, error)
+}
+
+// MigrationResult is the result of a step of Migrate
+type MigrationResult struct {
+	Name       string            // 合约名
+	Address    common.Address    // 合约地址
+	Skipped    bool              // 合约已部署且未改变，未重新部署
+	Deployment *DeploymentResult // 部署结果，跳过时为nil
+}
+
+// Migrate runs the migrations in order and records the deployments to the manifest set by WithManifestDir.
+// A step is skipped if the manifest records the contract deployed with the same code and arguments, and
+// the code at its address still has the recorded hash. The results of the steps run are returned along
+// with the error of the first step failed.
+func (rpc *EthRPC) Migrate(privKey *ecdsa.PrivateKey, migrations []Migration, opts ...TransactionOption) ([]*MigrationResult, error) {
+	if rpc.manifest == nil {
+		return nil, fmt.Errorf("migrate: no manifest, see WithManifestDir")
+	}
+	addresses := make(map[string]common.Address)
+	for _, name := range rpc.manifest.Names() {
+		entry, _ := rpc.manifest.Get(name)
+		addresses[name] = common.HexToAddress(entry.Address)
+	}
+
+	var results []*MigrationResult
+	for _, migration := range migrations {
+		var args []interface{}
+		if migration.Args != nil {
+			var err error
+			if args, err = migration.Args(addresses); err != nil {
+				return results, fmt.Errorf("migrate %s: %w", migration.Name, err)
+			}
+		}
+		initCode, err := InitCode(migration.Abi, migration.Code, args...)
+		if err != nil {
+			return results, fmt.Errorf("migrate %s: %w", migration.Name, err)
+		}
+		deployed, err := rpc.deployed(migration.Name, initCode)
+		if err != nil {
+			return results, fmt.Errorf("migrate %s: %w", migration.Name, err)
+		}
+		if deployed != nil {
+			rpc.logger.Infof("Migrate %s: deployed at %s already", migration.Name, deployed)
+			addresses[migration.Name] = *deployed
+			results = append(results, &MigrationResult{Name: migration.Name, Address: *deployed, Skipped: true})
+			continue
+		}
+
+		res, err := rpc.deployCode(migration.Name, privKey, migration.Abi, migration.Code, args, opts...)
+		if err != nil {
+			return results, fmt.Errorf("migrate %s: %w", migration.Name, err)
+		}
+		rpc.logger.Infof("Migrate %s: deployed at %s", migration.Name, res.Address)
+		opts = nextNonce(opts, res.Nonce)
+		addresses[migration.Name] = res.Address
+		results = append(results, &MigrationResult{Name: migration.Name, Address: res.Address, Deployment: res})
+	}
+	return results, nil
+}
+
+// deployed returns the address of the contract recorded by name if it is deployed with initCode
+// and its code on chain is unchanged
+func (rpc *EthRPC) deployed(name string, initCode []byte) (*common.Address, error) {
+	entry, ok := rpc.manifest.Get(name)
+	if !ok || entry.BytecodeHash == "" || entry.InitCodeHash != crypto.Keccak256Hash(initCode).String() {
+		return nil, nil
+	}
+	address := common.HexToAddress(entry.Address)
+	code, err := rpc.EthGetCode(address, nil)
+	if err != nil {
+		return nil, err
+	}
+	if code == "0x" || crypto.Keccak256Hash(common.FromHex(code)).String() != entry.BytecodeHash {
+		return nil, nil
+	}
+	return &address, nil
+}
@@ -0,0 +1,224 @@
+package go_eth_client
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/meshplus/go-eth-client/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// peerAbi is storedAbi with a constructor taking the address of a peer, which the code ignores
+const peerAbi = `[{"inputs":[{"internalType":"address","name":"peer","type":"address"}],"stateMutability":"nonpayable","type":"constructor"}]`
+
+func newManifestSimulated(t *testing.T, dir string) *Simulated {
+	pk, err := crypto.GenerateKey()
+	require.Nil(t, err)
+	sim, err := NewSimulated(core.GenesisAlloc{
+		crypto.PubkeyToAddress(pk.PublicKey): {Balance: new(big.Int).Exp(big.NewInt(10), big.NewInt(30), nil)},
+	}, WithPriKey(pk), WithManifestDir(dir))
+	require.Nil(t, err)
+	return sim
+}
+
+func TestManifest(t *testing.T) {
+	dir := t.TempDir()
+	manifest, err := LoadManifest(dir, big.NewInt(1356))
+	require.Nil(t, err)
+	assert.Equal(t, filepath.Join(dir, "1356.json"), manifest.Path())
+	assert.Empty(t, manifest.Names())
+
+	entry := &ManifestEntry{Name: "Broker", Address: "0x00000000000000000000000000000000000000aa", TxHash: "0x01",
+		InitCodeHash: "0x02", BytecodeHash: "0x03", Args: []interface{}{"appchain"}}
+	require.Nil(t, manifest.Record(entry))
+	require.Nil(t, manifest.Record(&ManifestEntry{Name: "Data", Address: "0x00000000000000000000000000000000000000bb"}))
+
+	loaded, err := LoadManifest(dir, big.NewInt(1356))
+	require.Nil(t, err)
+	assert.Equal(t, []string{"Broker", "Data"}, loaded.Names())
+	got, ok := loaded.Get("Broker")
+	require.True(t, ok)
+	assert.Equal(t, entry, got)
+
+	// the manifests of the chains are kept apart
+	other, err := LoadManifest(dir, big.NewInt(1))
+	require.Nil(t, err)
+	assert.Empty(t, other.Names())
+	require.Nil(t, ioutil.WriteFile(filepath.Join(dir, "2.json"), []byte(`{"chainId":"1356"}`), 0644))
+	_, err = LoadManifest(dir, big.NewInt(2))
+	require.NotNil(t, err)
+	_, err = LoadManifest(dir, nil)
+	require.NotNil(t, err)
+}
+
+func TestManifestSharedDir(t *testing.T) {
+	dir := t.TempDir()
+	var manifests []*Manifest
+	for i := 0; i < 2; i++ {
+		manifest, err := LoadManifest(dir, big.NewInt(1356))
+		require.Nil(t, err)
+		manifests = append(manifests, manifest)
+	}
+
+	// the clients sharing the file keep the entries of each other
+	var wg sync.WaitGroup
+	for i, manifest := range manifests {
+		wg.Add(1)
+		go func(i int, manifest *Manifest) {
+			defer wg.Done()
+			for j := 0; j < 10; j++ {
+				assert.Nil(t, manifest.Record(&ManifestEntry{Name: fmt.Sprintf("Contract%d-%d", i, j)}))
+			}
+		}(i, manifest)
+	}
+	wg.Wait()
+	loaded, err := LoadManifest(dir, big.NewInt(1356))
+	require.Nil(t, err)
+	assert.Equal(t, 20, len(loaded.Names()))
+	_, err = os.Stat(manifests[0].Path() + ".lock")
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestSimulatedManifest(t *testing.T) {
+	dir := t.TempDir()
+	sim := newManifestSimulated(t, dir)
+	defer sim.Stop()
+
+	storageAbi, err := utils.LoadAbi("./testdata/storage.abi")
+	require.Nil(t, err)
+	code, err := ioutil.ReadFile("./testdata/storage.bin")
+	require.Nil(t, err)
+	address, _, err := sim.DeployByCode(nil, storageAbi, string(code), nil)
+	require.Nil(t, err)
+	artifacts, err := LoadArtifacts("./testdata/artifacts/hardhat/artifacts")
+	require.Nil(t, err)
+	linked, err := sim.DeployContracts(nil, NewCompileResult(artifacts...), map[string][]interface{}{"Token": nil})
+	require.Nil(t, err)
+
+	data, err := ioutil.ReadFile(filepath.Join(dir, sim.EthGetChainId().String()+".json"))
+	require.Nil(t, err)
+	var manifest Manifest
+	require.Nil(t, json.Unmarshal(data, &manifest))
+	assert.Equal(t, sim.EthGetChainId().String(), manifest.ChainId)
+	assert.Equal(t, 3, len(manifest.Contracts))
+
+	entry := manifest.Contracts[address]
+	require.NotNil(t, entry)
+	assert.Equal(t, address, entry.Address)
+	onChain, err := sim.EthGetCode(common.HexToAddress(address), nil)
+	require.Nil(t, err)
+	assert.Equal(t, crypto.Keccak256Hash(common.FromHex(onChain)).String(), entry.BytecodeHash)
+	assert.Equal(t, crypto.Keccak256Hash(common.FromHex(string(code))).String(), entry.InitCodeHash)
+	for _, name := range []string{"contracts/Math.sol:Math", "contracts/Token.sol:Token"} {
+		require.NotNil(t, manifest.Contracts[name], name)
+		assert.Equal(t, linked[name], manifest.Contracts[name].Address)
+		assert.NotZero(t, manifest.Contracts[name].BlockNumber)
+	}
+	assert.Equal(t, sim.Manifest().Names(), []string{address, "contracts/Math.sol:Math", "contracts/Token.sol:Token"})
+
+	// Deploy waits for the receipts to record the deployments
+	sent, err := sim.Deploy(nil, NewCompileResult(artifacts[1]), nil)
+	require.Nil(t, err)
+	require.NotEqual(t, linked["contracts/Math.sol:Math"], sent[0])
+	entry, ok := sim.Manifest().Get("contracts/Math.sol:Math")
+	require.True(t, ok)
+	assert.Equal(t, sent[0], entry.Address)
+
+	// a contract deployed through CREATE2 is recorded by its name
+	create2, err := sim.DeployCreate2(nil, "Storage", common.HexToHash("0x01"), storageAbi, string(code), nil)
+	require.Nil(t, err)
+	entry, ok = sim.Manifest().Get("Storage")
+	require.True(t, ok)
+	assert.Equal(t, create2.Address.String(), entry.Address)
+}
+
+func TestSimulatedMigrate(t *testing.T) {
+	dir := t.TempDir()
+	sim := newManifestSimulated(t, dir)
+	defer sim.Stop()
+
+	plain, _ := newSimulated(t)
+	defer plain.Stop()
+	_, err := plain.Migrate(nil, nil)
+	require.NotNil(t, err)
+
+	contractAbi, err := abi.JSON(strings.NewReader(storedAbi))
+	require.Nil(t, err)
+	constructorAbi, err := abi.JSON(strings.NewReader(peerAbi))
+	require.Nil(t, err)
+	peer := "Stored"
+	migrations := []Migration{
+		{Name: "Stored", Abi: contractAbi, Code: storedCode()},
+		{Name: "Other", Abi: contractAbi, Code: storedCode() + "00"},
+		{Name: "Peer", Abi: constructorAbi, Code: storedCode(), Args: func(addresses map[string]common.Address) ([]interface{}, error) {
+			return []interface{}{addresses[peer]}, nil
+		}},
+	}
+	results, err := sim.Migrate(nil, migrations)
+	require.Nil(t, err)
+	require.Equal(t, 3, len(results))
+	for _, res := range results {
+		require.False(t, res.Skipped)
+		require.Equal(t, res.Address, res.Deployment.Address)
+	}
+	entry, ok := sim.Manifest().Get("Peer")
+	require.True(t, ok)
+	require.Equal(t, []interface{}{results[0].Address}, entry.Args)
+
+	// nothing changed
+	again, err := sim.Migrate(nil, migrations)
+	require.Nil(t, err)
+	for i, res := range again {
+		require.True(t, res.Skipped)
+		require.Nil(t, res.Deployment)
+		require.Equal(t, results[i].Address, res.Address)
+	}
+
+	// the arguments of Peer change
+	peer = "Other"
+	changed, err := sim.Migrate(nil, migrations)
+	require.Nil(t, err)
+	require.True(t, changed[0].Skipped)
+	require.True(t, changed[1].Skipped)
+	require.False(t, changed[2].Skipped)
+	require.NotEqual(t, results[2].Address, changed[2].Address)
+
+	// the manifest is loaded by a new client of a chain where the contracts are missing
+	fresh := newManifestSimulated(t, dir)
+	defer fresh.Stop()
+	require.Equal(t, sim.Manifest().Names(), fresh.Manifest().Names())
+	redeployed, err := fresh.Migrate(nil, migrations)
+	require.Nil(t, err)
+	for _, res := range redeployed {
+		require.False(t, res.Skipped)
+	}
+
+	// the steps run get consecutive nonces after an explicit one
+	from := crypto.PubkeyToAddress(sim.privateKey.PublicKey)
+	nonce, err := sim.EthGetTransactionCount(from, nil)
+	require.Nil(t, err)
+	peer = "Stored"
+	migrations[0].Code, migrations[1].Code = storedCode()+"0000", storedCode()+"000000"
+	renewed, err := sim.Migrate(nil, migrations, WithNonce(nonce))
+	require.Nil(t, err)
+	for i, res := range renewed {
+		require.False(t, res.Skipped)
+		require.Equal(t, PredictAddress(from, nonce+uint64(i)), res.Address)
+	}
+
+	_, err = sim.Migrate(nil, []Migration{{Name: "Broken", Abi: constructorAbi, Code: storedCode()}})
+	require.NotNil(t, err)
+	assert.Contains(t, err.Error(), "migrate Broken")
+}
@@ -3,10 +3,12 @@ package go_eth_client
 import (
 	"context"
 	"fmt"
+	"math/big"
 	"sync"
 	"time"
 
-	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 )
 
 const (
@@ -15,8 +17,18 @@ const (
 	defaultIdleTimeout = 6 * time.Minute
 )
 
+// Conn is the connection to a node used by EthRPC, it is implemented by *ethclient.Client
+type Conn interface {
+	bind.ContractBackend
+	ethereum.ChainReader
+	ethereum.TransactionReader
+	ethereum.ChainStateReader
+	ChainID(ctx context.Context) (*big.Int, error)
+	Close()
+}
+
 // Factory is a function type creating an eth client and return url of client
-type Factory func() (*ethclient.Client, string, error)
+type Factory func() (Conn, string, error)
 
 // Pool is the eth client pool
 type Pool struct {
@@ -28,7 +40,7 @@ type Pool struct {
 
 // clientConn is the wrapper for an eth client conn
 type clientConn struct {
-	conn     *ethclient.Client
+	conn     Conn
 	url      string
 	timeUsed time.Time
 }
@@ -0,0 +1,59 @@
+package go_eth_client
+
+import (
+	"math/big"
+	"net/http"
+	"path/filepath"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/meshplus/go-eth-client/devchain"
+	"github.com/meshplus/go-eth-client/rpcreplay"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecordReplayTransfer(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "transfer.jsonl")
+	chain, err := devchain.Start(devchain.WithAccounts(1))
+	require.Nil(t, err)
+	key := chain.Accounts()[0]
+	to := common.Address{0x1}
+
+	transfer := func(cli *EthRPC) *types.Receipt {
+		nonce, err := cli.EthGetTransactionCount(crypto.PubkeyToAddress(key.PublicKey), nil)
+		require.Nil(t, err)
+		price, err := cli.EthGasPrice()
+		require.Nil(t, err)
+		tx := types.NewTransaction(nonce, to, big.NewInt(1), 21000, price, nil)
+		receipt, err := cli.EthSendTransactionWithReceipt(key, tx)
+		require.Nil(t, err)
+		return receipt
+	}
+
+	recorder, err := rpcreplay.NewRecorder(path, nil)
+	require.Nil(t, err)
+	cli, err := New(WithUrls(chain.URLs()), WithHTTPClient(&http.Client{Transport: recorder}))
+	require.Nil(t, err)
+	recorded := transfer(cli)
+	cli.Stop()
+	require.Nil(t, recorder.Close())
+	chain.Close()
+
+	replayer, err := rpcreplay.NewReplayer(path)
+	require.Nil(t, err)
+	cli, err = New(WithUrls([]string{"http://replay"}), WithHTTPClient(&http.Client{Transport: replayer}))
+	require.Nil(t, err)
+	defer cli.Stop()
+	replayed := transfer(cli)
+	assert.Equal(t, recorded.TxHash, replayed.TxHash)
+	assert.Equal(t, recorded.BlockHash, replayed.BlockHash)
+	assert.Empty(t, replayer.Remaining())
+
+	// an unexpected request is reported instead of sent to the node
+	_, err = cli.EthGetBalance(to, nil)
+	require.NotNil(t, err)
+	assert.Contains(t, err.Error(), "unexpected request eth_getBalance")
+}
@@ -0,0 +1,151 @@
+package go_eth_client
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/meshplus/go-eth-client/devchain"
+	"github.com/meshplus/go-eth-client/faultproxy"
+	"github.com/meshplus/go-eth-client/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newFaultyClient starts a dev chain with one proxy in front of each node
+func newFaultyClient(t *testing.T, nodes int, opts ...Option) (*devchain.DevChain, []*faultproxy.Proxy, *EthRPC, common.Address) {
+	chain, err := devchain.Start(devchain.WithNodes(nodes), devchain.WithAccounts(1))
+	require.Nil(t, err)
+	var (
+		proxies []*faultproxy.Proxy
+		urls    []string
+	)
+	for _, url := range chain.URLs() {
+		proxy, err := faultproxy.New(url)
+		require.Nil(t, err)
+		proxies = append(proxies, proxy)
+		urls = append(urls, proxy.URL())
+	}
+	cli, err := New(append([]Option{WithUrls(urls), WithPoolInit(1), WithPoolSize(1)}, opts...)...)
+	require.Nil(t, err)
+	return chain, proxies, cli, crypto.PubkeyToAddress(chain.Accounts()[0].PublicKey)
+}
+
+func closeFaultyClient(chain *devchain.DevChain, proxies []*faultproxy.Proxy, cli *EthRPC) {
+	cli.Stop()
+	for _, proxy := range proxies {
+		_ = proxy.Close()
+	}
+	chain.Close()
+}
+
+func TestResilienceTransientFaults(t *testing.T) {
+	chain, proxies, cli, address := newFaultyClient(t, 1)
+	defer closeFaultyClient(chain, proxies, cli)
+	proxy := proxies[0]
+
+	// slow, dropped and overloaded requests are retried until the node answers
+	proxy.On("eth_getBalance",
+		faultproxy.Fault{Latency: 100 * time.Millisecond},
+		faultproxy.Fault{Drop: true},
+		faultproxy.Fault{StatusCode: 503},
+		faultproxy.Fault{StatusCode: 429},
+	)
+	for i := 0; i < 3; i++ {
+		balance, err := cli.EthGetBalance(address, nil)
+		require.Nil(t, err)
+		assert.True(t, balance.Sign() > 0)
+	}
+	assert.Equal(t, 6, proxy.Count("eth_getBalance"))
+}
+
+func TestResilienceMalformedResponse(t *testing.T) {
+	chain, proxies, cli, address := newFaultyClient(t, 1)
+	defer closeFaultyClient(chain, proxies, cli)
+	proxy := proxies[0]
+
+	// a broken response is an answer of the node, it is reported instead of retried
+	proxy.On("eth_getBalance", faultproxy.Fault{Malformed: true})
+	_, err := cli.EthGetBalance(address, nil)
+	require.NotNil(t, err)
+	assert.Equal(t, 1, proxy.Count("eth_getBalance"))
+
+	_, err = cli.EthGetBalance(address, nil)
+	require.Nil(t, err)
+}
+
+func TestResilienceStall(t *testing.T) {
+	chain, proxies, cli, address := newFaultyClient(t, 1, WithCallTimeout(300*time.Millisecond))
+	defer closeFaultyClient(chain, proxies, cli)
+	proxy := proxies[0]
+
+	// a stalled node is given up after the call timeout
+	proxy.Always("eth_getBalance", faultproxy.Fault{Stall: true})
+	start := time.Now()
+	_, err := cli.EthGetBalance(address, nil)
+	require.NotNil(t, err)
+	assert.True(t, time.Since(start) < 5*time.Second)
+
+	proxy.Reset()
+	_, err = cli.EthGetBalance(address, nil)
+	require.Nil(t, err)
+}
+
+func TestResilienceOverloadedNode(t *testing.T) {
+	chain, proxies, cli, address := newFaultyClient(t, 2)
+	defer closeFaultyClient(chain, proxies, cli)
+
+	// every request reaching the overloaded node is answered by the other one
+	proxies[0].Always(faultproxy.AnyMethod, faultproxy.Fault{StatusCode: 503})
+	for i := 0; i < 4; i++ {
+		_, err := cli.EthGetBalance(address, nil)
+		require.Nil(t, err)
+	}
+	assert.Equal(t, 4, proxies[1].Count("eth_getBalance"))
+}
+
+func TestResilienceStaleNode(t *testing.T) {
+	chain, proxies, cli, _ := newFaultyClient(t, 1)
+	defer closeFaultyClient(chain, proxies, cli)
+	proxy := proxies[0]
+
+	block, err := cli.EthGetBlockByNumber(nil, false)
+	require.Nil(t, err)
+	require.Nil(t, chain.Mine())
+
+	// a lagging node can't be told apart from a healthy one, its data is returned as is
+	proxy.On("eth_getBlockByNumber", faultproxy.Fault{Stale: true})
+	stale, err := cli.EthGetBlockByNumber(nil, false)
+	require.Nil(t, err)
+	assert.Equal(t, block.NumberU64(), stale.NumberU64())
+	latest, err := cli.EthGetBlockByNumber(nil, false)
+	require.Nil(t, err)
+	assert.Equal(t, block.NumberU64()+1, latest.NumberU64())
+}
+
+func TestResilienceSendTransaction(t *testing.T) {
+	chain, proxies, cli, address := newFaultyClient(t, 1)
+	defer closeFaultyClient(chain, proxies, cli)
+	proxy := proxies[0]
+	key := chain.Accounts()[0]
+
+	// a refused transaction is sent again
+	proxy.On("eth_sendRawTransaction", faultproxy.Fault{StatusCode: 503})
+	nonce, err := cli.EthGetTransactionCount(address, nil)
+	require.Nil(t, err)
+	price, err := cli.EthGasPrice()
+	require.Nil(t, err)
+	tx := utils.NewTransaction(nonce, address, 21000, price, nil, big.NewInt(1))
+	_, err = cli.EthSendTransaction(key, tx)
+	require.Nil(t, err)
+	assert.Equal(t, 2, proxy.Count("eth_sendRawTransaction"))
+
+	// a transaction which may have reached the node is not sent twice
+	proxy.On("eth_sendRawTransaction", faultproxy.Fault{Drop: true})
+	tx = utils.NewTransaction(nonce+1, address, 21000, price, nil, big.NewInt(1))
+	_, err = cli.EthSendTransaction(key, tx)
+	require.NotNil(t, err)
+	assert.Equal(t, 3, proxy.Count("eth_sendRawTransaction"))
+}
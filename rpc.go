@@ -3,11 +3,18 @@ package go_eth_client
 import (
 	"context"
 	"crypto/ecdsa"
-	"encoding/json"
+	"crypto/tls"
+	"errors"
 	"fmt"
+	"io"
 	"math/big"
 	"math/rand"
+	"net/http"
+	"net/url"
+	"sort"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/Rican7/retry"
@@ -17,11 +24,12 @@ import (
 	"github.com/ethereum/go-ethereum/accounts/abi"
 	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	"github.com/ethereum/go-ethereum/common"
-	"github.com/ethereum/go-ethereum/common/compiler"
 	"github.com/ethereum/go-ethereum/core/types"
-	"github.com/ethereum/go-ethereum/crypto"
-	"github.com/ethereum/go-ethereum/ethclient"
+	ethrpc "github.com/ethereum/go-ethereum/rpc"
 	"github.com/meshplus/bitxhub-kit/log"
+	"github.com/meshplus/go-eth-client/compiler"
+	"github.com/meshplus/go-eth-client/config"
+	"github.com/meshplus/go-eth-client/signer"
 	"github.com/meshplus/go-eth-client/utils"
 )
 
@@ -33,18 +41,32 @@ const (
 	defaultPoolIdleTimeout = 1 * time.Hour   // 连接池中连接的默认闲置时间阈值
 	defaultCallTimeout     = 6 * time.Second // 默认请求超时时间
 
-	waitReceipt = 300 * time.Millisecond
+	waitReceipt      = 300 * time.Millisecond
+	failedUrlTimeout = 10 * time.Second // 连接失败的节点在此时间内不再被连接
 )
 
 type EthRPC struct {
-	urls            []string          // bitxhub各节点的URL
-	privateKey      *ecdsa.PrivateKey // 用于交易签名的默认私钥
-	cid             *big.Int          // ChainID
-	pool            *Pool             // 客户端连接池
-	poolSize        int               // 连接池大小
-	poolInit        int               // 连接池初始连接数
-	poolIdleTimeout time.Duration     // 连接池中连接的闲置时间阈值
-	callTimeout     time.Duration     // 请求的超时时间（包括等待连接和json-rpc请求的超时时间总和）
+	urls            []string                              // bitxhub各节点的URL
+	privateKey      *ecdsa.PrivateKey                     // 用于交易签名的默认私钥
+	signer          signer.Signer                         // 未指定私钥时用于交易签名的默认签名者
+	compiler        *compiler.Compiler                    // 编译合约使用的solc及编译设置
+	manifestDir     string                                // 部署清单所在目录，为空时不记录部署
+	manifest        *Manifest                             // 当前链的部署清单
+	cid             *big.Int                              // ChainID
+	pool            *Pool                                 // 客户端连接池
+	poolSize        int                                   // 连接池大小
+	poolInit        int                                   // 连接池初始连接数
+	poolIdleTimeout time.Duration                         // 连接池中连接的闲置时间阈值
+	callTimeout     time.Duration                         // 请求的超时时间（包括等待连接和json-rpc请求的超时时间总和）
+	httpClient      *http.Client                          // 自定义的HTTP客户端
+	headers         map[string]http.Header                // 各节点URL附加的请求头，空URL表示所有节点
+	headerFunc      HeaderFunc                            // 每次请求时动态获取请求头
+	tlsConfig       *tls.Config                           // TLS配置
+	proxy           func(*http.Request) (*url.URL, error) // 代理设置
+	httpTimeout     time.Duration                         // HTTP客户端超时时间
+	factory         Factory                               // 连接池创建连接的方法，默认连接urls中的节点
+	next            uint32                                // 下一个连接的节点在urls中的序号
+	failed          sync.Map                              // 连接失败的节点URL及失败时间
 	logger          Logger
 }
 
@@ -62,6 +84,28 @@ func WithPriKey(pk *ecdsa.PrivateKey) Option {
 	}
 }
 
+// WithDefaultSigner sets the signer of the write methods called without a private key,
+// it takes precedence over the key of WithPriKey
+func WithDefaultSigner(s signer.Signer) Option {
+	return func(config *EthRPC) {
+		config.signer = s
+	}
+}
+
+// WithCompiler sets the solc, the settings and the cache used by Compile
+func WithCompiler(c *compiler.Compiler) Option {
+	return func(config *EthRPC) {
+		config.compiler = c
+	}
+}
+
+// WithManifestDir records the contracts deployed and mined to the manifest of the chain in dir, named by the chain id
+func WithManifestDir(dir string) Option {
+	return func(config *EthRPC) {
+		config.manifestDir = dir
+	}
+}
+
 func WithPoolSize(poolSize int) Option {
 	return func(config *EthRPC) {
 		config.poolSize = poolSize
@@ -118,10 +162,25 @@ func New(opts ...Option) (*EthRPC, error) {
 	if rpc.logger == nil {
 		rpc.logger = log.NewWithModule("go-eth-client")
 	}
+	if rpc.compiler == nil {
+		rpc.compiler = compiler.New(compiler.WithLogger(rpc.logger))
+	}
+	if rpc.signer == nil && rpc.privateKey != nil {
+		rpc.signer = signer.NewKeySigner(rpc.privateKey)
+	}
+
+	if rpc.factory == nil {
+		if err := rpc.checkTransport(); err != nil {
+			return nil, err
+		}
+		// start from a random node to spread the load of clients
+		rpc.next = uint32(rand.New(rand.NewSource(time.Now().UnixNano())).Intn(len(rpc.urls)))
+		rpc.factory = rpc.newClient
+	}
 
 	// generate other config
 	var err error
-	rpc.pool, err = NewPool(rpc.newClient, rpc.poolInit, rpc.poolSize, rpc.poolIdleTimeout)
+	rpc.pool, err = NewPool(rpc.factory, rpc.poolInit, rpc.poolSize, rpc.poolIdleTimeout)
 	if err != nil {
 		return nil, err
 	}
@@ -134,19 +193,92 @@ func New(opts ...Option) (*EthRPC, error) {
 	}); err != nil {
 		return nil, err
 	}
+	if rpc.manifestDir != "" {
+		if rpc.manifest, err = LoadManifest(rpc.manifestDir, rpc.cid); err != nil {
+			return nil, err
+		}
+	}
 	return rpc, nil
 }
 
-func (rpc *EthRPC) newClient() (*ethclient.Client, string, error) {
-	randIndex := rand.New(rand.NewSource(time.Now().UnixNano())).Intn(len(rpc.urls))
+// NewFromConfig creates an EthRPC with the urls and transport settings in cfg,
+// opts are applied after them and can override any of them.
+func NewFromConfig(cfg *config.Config, opts ...Option) (*EthRPC, error) {
+	transport := cfg.Transport
+	tlsConfig, err := transport.TLS.LoadTLSConfig()
+	if err != nil {
+		return nil, err
+	}
+	options := []Option{
+		WithUrls(append(append([]string{}, cfg.Addrs...), cfg.IpcAddrs...)),
+		WithTLSConfig(tlsConfig),
+		WithHTTPTimeout(transport.Timeout),
+	}
+	if transport.Proxy != "" {
+		proxyUrl, err := url.Parse(transport.Proxy)
+		if err != nil {
+			return nil, fmt.Errorf("parse proxy url %s: %w", transport.Proxy, err)
+		}
+		options = append(options, WithProxy(http.ProxyURL(proxyUrl)))
+	}
+	for key, value := range transport.Headers {
+		options = append(options, WithHeader("", key, value))
+	}
+	if transport.BearerToken != "" {
+		options = append(options, WithHeader("", "Authorization", "Bearer "+transport.BearerToken))
+	}
+	for _, endpoint := range transport.Endpoints {
+		for key, value := range endpoint.Headers {
+			options = append(options, WithHeader(endpoint.Url, key, value))
+		}
+		if endpoint.BearerToken != "" {
+			options = append(options, WithHeader(endpoint.Url, "Authorization", "Bearer "+endpoint.BearerToken))
+		}
+	}
+	if cfg.Account.Keystore != "" {
+		privKey, err := loadAccount(cfg.Account)
+		if err != nil {
+			return nil, err
+		}
+		options = append(options, WithPriKey(privKey))
+	}
+	return New(append(options, opts...)...)
+}
+
+// loadAccount decrypts the keystore of the default account with the configured password
+func loadAccount(account config.Account) (*ecdsa.PrivateKey, error) {
+	password := account.Password
+	if account.PasswordFile != "" {
+		var err error
+		if password, err = utils.PasswordFile(account.PasswordFile)(common.Address{}); err != nil {
+			return nil, err
+		}
+	}
+	privKey, _, err := utils.KeystoreToPrivateKey(account.Keystore, password)
+	if err != nil {
+		return nil, fmt.Errorf("load default account: %w", err)
+	}
+	return privKey, nil
+}
+
+// newClient dials the urls in turn and skips the nodes failed recently,
+// unless all of them failed
+func (rpc *EthRPC) newClient() (Conn, string, error) {
+	index := int(atomic.AddUint32(&rpc.next, 1) % uint32(len(rpc.urls)))
+	for i := 0; i < len(rpc.urls); i++ {
+		if next := (index + i) % len(rpc.urls); !rpc.isFailed(rpc.urls[next]) {
+			index = next
+			break
+		}
+	}
 	// Dial can't create connection, only create an instance
-	client, err := ethclient.Dial(rpc.urls[randIndex])
+	client, err := rpc.dial(context.Background(), rpc.urls[index])
 	if err != nil {
-		rpc.logger.Errorf("Dial url %s failed", rpc.urls[randIndex])
-		return nil, "", fmt.Errorf("dial url %s failed", rpc.urls[randIndex])
+		rpc.logger.Errorf("Dial url %s failed", rpc.urls[index])
+		return nil, "", fmt.Errorf("dial url %s failed", rpc.urls[index])
 	}
-	rpc.logger.Debugf("Create instance that dial with %s successfully", rpc.urls[randIndex])
-	return client, rpc.urls[randIndex], nil
+	rpc.logger.Debugf("Create instance that dial with %s successfully", rpc.urls[index])
+	return client, rpc.urls[index], nil
 }
 
 func (rpc *EthRPC) putClient(client *clientConn) {
@@ -156,6 +288,18 @@ func (rpc *EthRPC) putClient(client *clientConn) {
 }
 
 func (rpc *EthRPC) wrapper(f func(ctx context.Context, client *clientConn) error) error {
+	return rpc.call(f, isRetryableErr)
+}
+
+// sendWrapper calls f like wrapper, but a request which may have reached the node is not
+// resent, or the node would see the transaction twice and report it as known already
+func (rpc *EthRPC) sendWrapper(f func(ctx context.Context, client *clientConn) error) error {
+	return rpc.call(f, isUnsentErr)
+}
+
+// call calls f on the nodes in turn until it succeeds or fails with an error which is
+// not retryable
+func (rpc *EthRPC) call(f func(ctx context.Context, client *clientConn) error, retryable func(error) bool) error {
 	var otherErr error
 	if err := retry.Retry(func(attempt uint) error {
 		ctx, cancel := context.WithTimeout(context.Background(), rpc.callTimeout)
@@ -165,13 +309,20 @@ func (rpc *EthRPC) wrapper(f func(ctx context.Context, client *clientConn) error
 			return err
 		}
 		defer rpc.putClient(client)
+		// don't wait on a connection to a node which is known to be down
+		if rpc.isFailed(client.url) {
+			client.Close()
+			if client.conn, client.url, err = rpc.factory(); err != nil {
+				return err
+			}
+		}
 		if err := retry.Retry(func(attempt uint) error {
 			ctx, cancel := context.WithTimeout(context.Background(), rpc.callTimeout)
 			defer cancel()
 			if err := f(ctx, client); err != nil {
 				rpc.logger.Warning(err.Error())
-				// if error is 'connection refused', retry
-				if strings.Contains(err.Error(), "connection refused") {
+				// if error is 'connection refused' or the node is overloaded, retry
+				if retryable(err) {
 					return err
 				}
 				otherErr = err
@@ -180,9 +331,11 @@ func (rpc *EthRPC) wrapper(f func(ctx context.Context, client *clientConn) error
 		}, strategy.Wait(200*time.Millisecond), strategy.Limit(3)); err != nil {
 			// if still failed after retry 5 times, close the client
 			client.Close()
+			rpc.failed.Store(client.url, time.Now())
 			rpc.logger.Errorf("close connection with %s", client.url)
 			return err
 		}
+		rpc.failed.Delete(client.url)
 		return nil
 	}, strategy.Wait(1*time.Second), strategy.Limit(uint(2*len(rpc.urls)))); err != nil {
 		return err
@@ -194,6 +347,48 @@ func (rpc *EthRPC) wrapper(f func(ctx context.Context, client *clientConn) error
 	return nil
 }
 
+// isFailed reports whether the node at url failed within failedUrlTimeout
+func (rpc *EthRPC) isFailed(url string) bool {
+	v, ok := rpc.failed.Load(url)
+	if !ok {
+		return false
+	}
+	return time.Since(v.(time.Time)) < failedUrlTimeout
+}
+
+// isRetryableErr reports whether err means the node can't serve the request for now,
+// over http or ipc
+func isRetryableErr(err error) bool {
+	// the keep-alive connection was closed by a restarted node
+	if errors.Is(err, io.EOF) {
+		return true
+	}
+	var httpErr ethrpc.HTTPError
+	if errors.As(err, &httpErr) {
+		switch httpErr.StatusCode {
+		case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+			return true
+		}
+	}
+	msg := err.Error()
+	return strings.Contains(msg, "connection refused") ||
+		strings.Contains(msg, "connection reset by peer") ||
+		strings.Contains(msg, "no such file or directory") ||
+		strings.Contains(msg, "broken pipe")
+}
+
+// isUnsentErr reports whether err means the node refused the request without handling it,
+// so it is safe to send it again
+func isUnsentErr(err error) bool {
+	var httpErr ethrpc.HTTPError
+	if errors.As(err, &httpErr) {
+		return httpErr.StatusCode == http.StatusTooManyRequests || httpErr.StatusCode == http.StatusServiceUnavailable
+	}
+	msg := err.Error()
+	return strings.Contains(msg, "connection refused") ||
+		strings.Contains(msg, "no such file or directory")
+}
+
 func (rpc *EthRPC) EthEstimateGas(msg ethereum.CallMsg) (uint64, error) {
 	var estimateGas uint64
 	if err := rpc.wrapper(func(ctx context.Context, client *clientConn) error {
@@ -285,88 +480,149 @@ func (rpc *EthRPC) EthGetBlockTransactionCountByNumber(blockNumber *big.Int) (ui
 }
 
 func (rpc *EthRPC) Compile(sourceFiles ...string) (*CompileResult, error) {
-	contracts, err := compiler.CompileSolidity("", sourceFiles...)
+	output, err := rpc.compiler.CompileFiles(sourceFiles...)
 	if err != nil {
-		return nil, fmt.Errorf("compile contract: %w", err)
+		return nil, err
 	}
-	var abis, bins, names []string
-	for name, contract := range contracts {
-		contractAbi, err := json.Marshal(contract.Info.AbiDefinition)
-		if err != nil {
-			return nil, fmt.Errorf("failed to parse ABIs from compiler output: %w", err)
+	return rpc.compileResult(output), nil
+}
+
+// CompileSources compiles the sources given as source unit names and contents, such as generated code,
+// the imports which are not among the sources are loaded by importer
+func (rpc *EthRPC) CompileSources(sources map[string]string, importer compiler.ImportFunc) (*CompileResult, error) {
+	output, err := rpc.compiler.CompileSources(sources, importer)
+	if err != nil {
+		return nil, err
+	}
+	return rpc.compileResult(output), nil
+}
+
+func (rpc *EthRPC) compileResult(output *compiler.Output) *CompileResult {
+	for _, warning := range output.Warnings() {
+		rpc.logger.Warningf("Compile %s", warning.Error())
+	}
+	var contracts []*compiler.Contract
+	for _, fileContracts := range output.Contracts {
+		for _, contract := range fileContracts {
+			contracts = append(contracts, contract)
 		}
-		abis = append(abis, string(contractAbi))
-		bins = append(bins, contract.Code)
-		names = append(names, name)
+	}
+	sort.Slice(contracts, func(i, j int) bool {
+		return contracts[i].Id() < contracts[j].Id()
+	})
+	var abis, bins, names []string
+	artifacts := make(Artifacts, len(contracts))
+	for _, contract := range contracts {
+		abis = append(abis, string(contract.Abi))
+		bins = append(bins, "0x"+contract.Bytecode.Object)
+		names = append(names, contract.Id())
+		artifacts[contract.Id()] = NewArtifact(contract)
 	}
 	return &CompileResult{
-		Abi:   abis,
-		Bin:   bins,
-		Names: names,
-	}, nil
+		Abi:       abis,
+		Bin:       bins,
+		Names:     names,
+		Artifacts: artifacts,
+	}
 }
 
 func (rpc *EthRPC) DeployByCode(privKey *ecdsa.PrivateKey, abi abi.ABI, code string, args []interface{}, opts ...TransactionOption) (string, uint64, error) {
-	// set transaction options
-	transactionOpts := &TransactionOptions{}
-	for _, opt := range opts {
-		opt(transactionOpts)
-	}
-
-	txOpts, err := bind.NewKeyedTransactorWithChainID(privKey, rpc.cid)
+	res, err := rpc.DeployByCodeWithResult(privKey, abi, code, args, opts...)
 	if err != nil {
 		return "", 0, err
 	}
-	txOpts.GasPrice = transactionOpts.GasPrice
+	return res.Address.String(), res.BlockNumber, nil
+}
 
-	if transactionOpts.Nonce == 0 {
-		nonce, err := rpc.EthGetTransactionCount(crypto.PubkeyToAddress(privKey.PublicKey), nil)
-		if err != nil {
-			return "", 0, err
-		}
-		transactionOpts.Nonce = nonce
-	}
+// DeployByCodeWithResult deploys code with args as DeployByCode does, and returns the result of the deployment
+// with the receipt. The result is returned along with the error if the deployment is mined but failed.
+func (rpc *EthRPC) DeployByCodeWithResult(privKey *ecdsa.PrivateKey, contractAbi abi.ABI, code string, args []interface{},
+	opts ...TransactionOption) (*DeploymentResult, error) {
+	return rpc.deployCode("", privKey, contractAbi, code, args, opts...)
+}
 
-	txOpts.Nonce = big.NewInt(int64(transactionOpts.Nonce))
-	if transactionOpts.GasLimit == 0 {
-		txOpts.GasLimit = 100000000
-	} else {
-		txOpts.GasLimit = transactionOpts.GasLimit
+// deployCode deploys code with args and records it to the manifest by name
+func (rpc *EthRPC) deployCode(name string, privKey *ecdsa.PrivateKey, contractAbi abi.ABI, code string, args []interface{},
+	opts ...TransactionOption) (*DeploymentResult, error) {
+
+	if err := checkLinked(code); err != nil {
+		return nil, err
+	}
+	txOpts, err := rpc.generateTxOpts(privKey, opts...)
+	if err != nil {
+		return nil, err
 	}
 
-	var (
-		address common.Address
-		tx      *types.Transaction
-		receipt *types.Receipt
-	)
+	var tx *types.Transaction
+	res := &DeploymentResult{Name: name}
 	// deploy contract
-	if err := rpc.wrapper(func(ctx context.Context, client *clientConn) error {
+	if err := rpc.sendWrapper(func(ctx context.Context, client *clientConn) error {
 		var err error
-		address, tx, _, err = bind.DeployContract(txOpts, abi, common.FromHex(code), client.conn, args...)
-		if err != nil {
-			return err
-		}
-		time.Sleep(waitReceipt)
-		if err := retry.Retry(func(attempt uint) error {
-			receipt, err = client.conn.TransactionReceipt(ctx, tx.Hash())
-			if err != nil {
-				return err
-			}
-			return nil
-		}, strategy.Limit(5), strategy.Backoff(backoff.Fibonacci(200*time.Millisecond))); err != nil {
-			return err
-		}
-		return nil
+		res.Address, tx, _, err = bind.DeployContract(txOpts, contractAbi, common.FromHex(code), client.conn, args...)
+		return err
 	}); err != nil {
-		return "", 0, err
+		return nil, err
+	}
+	res.TxHash, res.From, res.Nonce = tx.Hash(), txOpts.From, tx.Nonce()
+	if waited, err := rpc.waitDeployment(res, tx, &contractAbi); err != nil {
+		return waited, err
+	}
+	rpc.record(name, res, tx.Data(), args)
+	return res, nil
+}
+
+// waitDeployment fills res with the receipt of the deployment tx, res is returned along with the error
+// if the deployment failed
+func (rpc *EthRPC) waitDeployment(res *DeploymentResult, tx *types.Transaction, contractAbi *abi.ABI) (*DeploymentResult, error) {
+	time.Sleep(waitReceipt)
+	receipt, err := rpc.EthGetTransactionReceipt(tx.Hash())
+	if err != nil {
+		return nil, err
+	}
+	if err := rpc.applyReceipt(&res.TransactionResult, tx, receipt, contractAbi); err != nil {
+		return nil, err
 	}
 	if receipt.Status == types.ReceiptStatusFailed {
-		return "", 0, fmt.Errorf("deploy contract failed, tx hash is: %s", tx.Hash())
+		res.Err = fmt.Errorf("deploy contract failed, tx hash is: %s", tx.Hash())
+		return res, res.Err
 	}
-	return address.String(), receipt.BlockNumber.Uint64(), nil
+	return res, nil
 }
 
+// Deploy sends the deployments of the deployable contracts of result without waiting for the receipts. If some
+// of them fail, the addresses are returned along with a *DeploymentError, those of the failed ones are zero.
+// With a manifest set by WithManifestDir, the receipts are waited for as DeployWithReceipt does, so that the
+// deployments mined are recorded.
 func (rpc *EthRPC) Deploy(privKey *ecdsa.PrivateKey, result *CompileResult, args []interface{}, opts ...TransactionOption) ([]string, error) {
+	results, err := rpc.deployAll(rpc.manifest != nil, privKey, result, args, opts...)
+	if results == nil {
+		return nil, err
+	}
+	return deployedAddresses(results), err
+}
+
+// DeployWithReceipt is Deploy waiting for the receipts
+func (rpc *EthRPC) DeployWithReceipt(privKey *ecdsa.PrivateKey, result *CompileResult, args []interface{},
+	opts ...TransactionOption) ([]string, error) {
+
+	results, err := rpc.deployAll(true, privKey, result, args, opts...)
+	if results == nil {
+		return nil, err
+	}
+	return deployedAddresses(results), err
+}
+
+// DeployAll deploys the deployable contracts of result with args. The transactions are sent at once with consecutive
+// nonces and their receipts are waited for in parallel. A result is returned for every contract, if some of them
+// fail, the error is a *DeploymentError and the results of the others are valid.
+func (rpc *EthRPC) DeployAll(privKey *ecdsa.PrivateKey, result *CompileResult, args []interface{},
+	opts ...TransactionOption) ([]*DeploymentResult, error) {
+	return rpc.deployAll(true, privKey, result, args, opts...)
+}
+
+func (rpc *EthRPC) deployAll(withReceipt bool, privKey *ecdsa.PrivateKey, result *CompileResult, args []interface{},
+	opts ...TransactionOption) ([]*DeploymentResult, error) {
+
 	if len(result.Abi) == 0 || len(result.Bin) == 0 || len(result.Names) == 0 {
 		return nil, fmt.Errorf("empty contract")
 	}
@@ -376,87 +632,153 @@ func (rpc *EthRPC) Deploy(privKey *ecdsa.PrivateKey, result *CompileResult, args
 		return nil, err
 	}
 
-	addresses := make([]string, 0)
+	nonce := txOpts.Nonce.Uint64()
+	var (
+		results []*DeploymentResult
+		sent    []*sentDeployment
+		sendErr error
+	)
 	for i, bin := range result.Bin {
-		if bin == "0x" {
+		// interfaces and abstract contracts
+		if !result.deployable(i) {
+			continue
+		}
+		res := &DeploymentResult{Name: result.Names[i]}
+		results = append(results, res)
+		// the later nonces can't be mined once one is not sent
+		if sendErr != nil {
+			res.Err = fmt.Errorf("not sent for an earlier deployment failed: %w", sendErr)
 			continue
 		}
 		parsed, err := abi.JSON(strings.NewReader(result.Abi[i]))
 		if err != nil {
-			return nil, err
+			res.Err = err
+			continue
 		}
 		code := strings.TrimPrefix(strings.TrimSpace(bin), "0x")
+		if err := checkLinked(code); err != nil {
+			res.Err = err
+			continue
+		}
 
-		var address common.Address
+		contractOpts := *txOpts
+		contractOpts.Nonce = new(big.Int).SetUint64(nonce)
+		var tx *types.Transaction
 		// deploy contract
-		if err := rpc.wrapper(func(ctx context.Context, client *clientConn) error {
+		if err := rpc.sendWrapper(func(ctx context.Context, client *clientConn) error {
 			var err error
-			address, _, _, err = bind.DeployContract(txOpts, parsed, common.FromHex(code), client.conn, args...)
-			if err != nil {
-				return err
-			}
-			return nil
+			res.Address, tx, _, err = bind.DeployContract(&contractOpts, parsed, common.FromHex(code), client.conn, args...)
+			return err
 		}); err != nil {
-			return nil, err
+			res.Err = err
+			sendErr = err
+			continue
 		}
-		addresses = append(addresses, address.String())
+		res.TxHash, res.From, res.Nonce = tx.Hash(), txOpts.From, nonce
+		sent = append(sent, &sentDeployment{result: res, tx: tx, abi: parsed})
+		nonce++
 	}
-	return addresses, nil
-}
 
-func (rpc *EthRPC) DeployWithReceipt(privKey *ecdsa.PrivateKey, result *CompileResult, args []interface{},
-	opts ...TransactionOption) ([]string, error) {
-
-	if len(result.Abi) == 0 || len(result.Bin) == 0 || len(result.Names) == 0 {
-		return nil, fmt.Errorf("empty contract")
+	if withReceipt {
+		time.Sleep(waitReceipt)
+		var wg sync.WaitGroup
+		for _, deployment := range sent {
+			wg.Add(1)
+			go func(deployment *sentDeployment) {
+				defer wg.Done()
+				res := deployment.result
+				receipt, err := rpc.EthGetTransactionReceipt(res.TxHash)
+				if err != nil {
+					res.Err = err
+					return
+				}
+				if err := rpc.applyReceipt(&res.TransactionResult, deployment.tx, receipt, &deployment.abi); err != nil {
+					res.Err = err
+					return
+				}
+				if receipt.Status == types.ReceiptStatusFailed {
+					res.Err = fmt.Errorf("deploy contract failed, tx hash is: %s", res.TxHash)
+				}
+			}(deployment)
+		}
+		wg.Wait()
+	}
+	for _, deployment := range sent {
+		rpc.record(deployment.result.Name, deployment.result, deployment.tx.Data(), args)
 	}
 
-	txOpts, err := rpc.generateTxOpts(privKey, opts...)
-	if err != nil {
-		return nil, err
+	for _, res := range results {
+		if res.Err != nil {
+			return results, &DeploymentError{Results: results}
+		}
 	}
+	return results, nil
+}
 
-	addresses := make([]string, 0)
-	for i, bin := range result.Bin {
-		if bin == "0x" {
+// sentDeployment is a deployment waiting for its receipt
+type sentDeployment struct {
+	result *DeploymentResult
+	tx     *types.Transaction
+	abi    abi.ABI
+}
+
+// deployedAddresses returns the addresses of the contracts deployed, zero for those failed
+func deployedAddresses(results []*DeploymentResult) []string {
+	addresses := make([]string, 0, len(results))
+	for _, res := range results {
+		if res.Err != nil {
+			addresses = append(addresses, common.Address{}.String())
 			continue
 		}
-		parsed, err := abi.JSON(strings.NewReader(result.Abi[i]))
+		addresses = append(addresses, res.Address.String())
+	}
+	return addresses
+}
+
+// DeployContracts deploys the contracts of result named by args, by the fully qualified names or the contract
+// names, each with its own constructor arguments. The contracts are deployed one by one in the order of their names,
+// after the libraries they are linked with, and the addresses of both are returned by the fully qualified names.
+func (rpc *EthRPC) DeployContracts(privKey *ecdsa.PrivateKey, result *CompileResult, args map[string][]interface{},
+	opts ...TransactionOption) (map[string]string, error) {
+
+	if len(args) == 0 {
+		return nil, fmt.Errorf("empty contract")
+	}
+	artifacts := result.artifacts()
+	selected := make(map[string][]interface{}, len(args))
+	for name, contractArgs := range args {
+		artifact, err := artifacts.Get(name)
 		if err != nil {
 			return nil, err
 		}
-		code := strings.TrimPrefix(strings.TrimSpace(bin), "0x")
+		if !artifact.Deployable() {
+			return nil, fmt.Errorf("contract %s is abstract or an interface", artifact.Id())
+		}
+		if _, ok := selected[artifact.Id()]; ok {
+			return nil, fmt.Errorf("contract %s is selected twice", artifact.Id())
+		}
+		selected[artifact.Id()] = contractArgs
+	}
 
-		var (
-			address common.Address
-			tx      *types.Transaction
-			receipt *types.Receipt
-		)
-		// deploy contract
-		if err := rpc.wrapper(func(ctx context.Context, client *clientConn) error {
+	l := &linker{rpc: rpc, privKey: privKey, opts: opts, artifacts: artifacts, deployed: make(map[string]common.Address)}
+	addresses := make(map[string]string, len(selected))
+	for _, id := range artifacts.Ids() {
+		contractArgs, ok := selected[id]
+		if !ok {
+			continue
+		}
+		// a library selected may have been deployed as a library of an earlier contract
+		address, ok := l.deployed[id]
+		if !ok {
 			var err error
-			address, tx, _, err = bind.DeployContract(txOpts, parsed, common.FromHex(code), client.conn, args...)
-			if err != nil {
-				return err
-			}
-			time.Sleep(waitReceipt)
-			if err := retry.Retry(func(attempt uint) error {
-				receipt, err = client.conn.TransactionReceipt(ctx, tx.Hash())
-				if err != nil {
-					return err
-				}
-				return nil
-			}, strategy.Limit(5), strategy.Backoff(backoff.Fibonacci(200*time.Millisecond))); err != nil {
-				return err
+			if address, err = l.deploy(artifacts[id], contractArgs); err != nil {
+				return nil, err
 			}
-			return nil
-		}); err != nil {
-			return nil, err
 		}
-		if receipt.Status == types.ReceiptStatusFailed {
-			return nil, fmt.Errorf("deploy contract failed, tx hash is: %s", tx.Hash())
-		}
-		addresses = append(addresses, address.String())
+		addresses[id] = address.String()
+	}
+	for _, id := range l.linked {
+		addresses[id] = l.deployed[id].String()
 	}
 	return addresses, nil
 }
@@ -467,18 +789,15 @@ func (rpc *EthRPC) generateTxOpts(privKey *ecdsa.PrivateKey, opts ...Transaction
 	for _, opt := range opts {
 		opt(transactionOpts)
 	}
-	// load privateKey
-	if transactionOpts.PrivateKey != nil {
-		privKey = transactionOpts.PrivateKey
-	}
-	txOpts, err := bind.NewKeyedTransactorWithChainID(privKey, rpc.cid)
+	txSigner, err := rpc.signerOf(privKey, transactionOpts)
 	if err != nil {
 		return nil, err
 	}
+	txOpts := rpc.transactOpts(txSigner)
 	txOpts.GasPrice = transactionOpts.GasPrice
 
 	if transactionOpts.Nonce == 0 {
-		nonce, err := rpc.EthGetTransactionCount(crypto.PubkeyToAddress(privKey.PublicKey), nil)
+		nonce, err := rpc.EthGetTransactionCount(txSigner.Address(), nil)
 		if err != nil {
 			return nil, err
 		}
@@ -495,6 +814,46 @@ func (rpc *EthRPC) generateTxOpts(privKey *ecdsa.PrivateKey, opts ...Transaction
 	return txOpts, nil
 }
 
+// transactOpts returns the options of bind which sign with txSigner
+func (rpc *EthRPC) transactOpts(txSigner signer.Signer) *bind.TransactOpts {
+	return &bind.TransactOpts{
+		From: txSigner.Address(),
+		Signer: func(address common.Address, tx *types.Transaction) (*types.Transaction, error) {
+			if address != txSigner.Address() {
+				return nil, bind.ErrNotAuthorized
+			}
+			return txSigner.SignTx(tx, rpc.cid)
+		},
+	}
+}
+
+// signerOf returns the signer of a transaction, the signer in the options takes precedence over the private keys,
+// the default signer is used if none of them is given
+func (rpc *EthRPC) signerOf(privKey *ecdsa.PrivateKey, opts *TransactionOptions) (signer.Signer, error) {
+	if opts.Signer != nil {
+		return opts.Signer, nil
+	}
+	if opts.PrivateKey != nil {
+		privKey = opts.PrivateKey
+	}
+	if privKey != nil {
+		return signer.NewKeySigner(privKey), nil
+	}
+	return rpc.defaultSigner()
+}
+
+func (rpc *EthRPC) defaultSigner() (signer.Signer, error) {
+	if rpc.signer == nil {
+		return nil, fmt.Errorf("no private key or signer for the transaction")
+	}
+	return rpc.signer, nil
+}
+
+// DefaultSigner returns the signer of the write methods called without a private key, it is nil if not configured
+func (rpc *EthRPC) DefaultSigner() signer.Signer {
+	return rpc.signer
+}
+
 func (rpc *EthRPC) EthCall(contractAbi *abi.ABI, address string, method string, args []interface{}) ([]interface{}, error) {
 	var invokeRes []interface{}
 	to := common.HexToAddress(address)
@@ -503,6 +862,9 @@ func (rpc *EthRPC) EthCall(contractAbi *abi.ABI, address string, method string,
 		return nil, err
 	}
 	msg := ethereum.CallMsg{To: &to, Data: packed}
+	if rpc.signer != nil {
+		msg.From = rpc.signer.Address()
+	}
 	if !contractAbi.Methods[method].IsConstant() {
 		return nil, fmt.Errorf("EthCall function need the method is read-only")
 	}
@@ -546,20 +908,60 @@ func (rpc *EthRPC) InvokeWithReceipt(privKey *ecdsa.PrivateKey, contractAbi *abi
 	return rpc.invoke(true, privKey, contractAbi, address, method, args, opts...)
 }
 
+// InvokeWithResult invokes method of the contract at address as InvokeWithReceipt does, and returns the result
+// with the receipt and the events decoded, or the outputs of a read-only method. The result is returned along
+// with the error if the transaction is mined but failed.
+func (rpc *EthRPC) InvokeWithResult(privKey *ecdsa.PrivateKey, contractAbi *abi.ABI, address string, method string,
+	args []interface{}, opts ...TransactionOption) (*InvocationResult, error) {
+
+	res, err := rpc.invokeResult(true, privKey, contractAbi, address, method, args, opts...)
+	if err != nil {
+		return nil, err
+	}
+	if res.Mined() && res.Status == types.ReceiptStatusFailed {
+		return res, fmt.Errorf("invoke %s failed, tx hash is: %s", method, res.TxHash)
+	}
+	return res, nil
+}
+
 func (rpc *EthRPC) invoke(withReceipt bool, privKey *ecdsa.PrivateKey, contractAbi *abi.ABI, address string,
 	method string, args []interface{}, opts ...TransactionOption) ([]interface{}, error) {
 
-	var invokeRes []interface{}
+	res, err := rpc.invokeResult(withReceipt, privKey, contractAbi, address, method, args, opts...)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	// read-only methods are called without a transaction
+	case res.TxHash == (common.Hash{}):
+		return res.Outputs, nil
+	case res.Mined():
+		return []interface{}{res.Receipt}, nil
+	default:
+		return []interface{}{res.TxHash}, nil
+	}
+}
+
+func (rpc *EthRPC) invokeResult(withReceipt bool, privKey *ecdsa.PrivateKey, contractAbi *abi.ABI, address string,
+	method string, args []interface{}, opts ...TransactionOption) (*InvocationResult, error) {
+
 	txOpts := &TransactionOptions{}
 	for _, opt := range opts {
 		opt(txOpts)
 	}
-	from := crypto.PubkeyToAddress(privKey.PublicKey)
+	// read-only methods may be called without an account
+	var from common.Address
+	txSigner, signerErr := rpc.signerOf(privKey, txOpts)
+	if signerErr == nil {
+		from = txSigner.Address()
+	}
 	to := common.HexToAddress(address)
 	packed, err := contractAbi.Pack(method, args...)
 	if err != nil {
 		return nil, err
 	}
+	res := &InvocationResult{Method: method, To: to}
+	res.From = from
 	msg := ethereum.CallMsg{From: from, To: &to, Data: packed}
 	if contractAbi.Methods[method].IsConstant() {
 		var output []byte
@@ -582,15 +984,18 @@ func (rpc *EthRPC) invoke(withReceipt bool, privKey *ecdsa.PrivateKey, contractA
 			return nil, fmt.Errorf("output is empty")
 		}
 		// unpack result for display
-		invokeRes, err = utils.UnpackOutput(contractAbi, method, string(output))
+		res.Outputs, err = utils.UnpackOutput(contractAbi, method, string(output))
 		if err != nil {
 			return nil, err
 		}
-		return invokeRes, nil
+		return res, nil
+	}
+	if signerErr != nil {
+		return nil, signerErr
 	}
 
 	if txOpts.Nonce == 0 {
-		nonce, err := rpc.EthGetTransactionCount(crypto.PubkeyToAddress(privKey.PublicKey), nil)
+		nonce, err := rpc.EthGetTransactionCount(from, nil)
 		if err != nil {
 			return nil, err
 		}
@@ -608,18 +1013,87 @@ func (rpc *EthRPC) invoke(withReceipt bool, privKey *ecdsa.PrivateKey, contractA
 	}
 
 	tx := utils.NewTransaction(txOpts.Nonce, to, txOpts.GasLimit, txOpts.GasPrice, packed, nil)
+	res.Nonce = tx.Nonce()
 	if withReceipt {
-		receipt, err := rpc.EthSendTransactionWithReceipt(privKey, tx)
+		receipt, err := rpc.EthSendTransactionWithReceiptBySigner(txSigner, tx)
 		if err != nil {
 			return nil, fmt.Errorf("invoke err:%s", err)
 		}
-		return []interface{}{receipt}, nil
+		res.TxHash = receipt.TxHash
+		if err := rpc.applyReceipt(&res.TransactionResult, tx, receipt, contractAbi); err != nil {
+			return nil, err
+		}
+		return res, nil
 	}
-	hash, err := rpc.EthSendTransaction(privKey, tx)
+	hash, err := rpc.EthSendTransactionBySigner(txSigner, tx)
 	if err != nil {
 		return nil, fmt.Errorf("invoke err:%s", err)
 	}
-	return []interface{}{hash}, nil
+	res.TxHash = hash
+	return res, nil
+}
+
+// applyReceipt fills res with receipt of tx, the events are decoded by contractAbi
+func (rpc *EthRPC) applyReceipt(res *TransactionResult, tx *types.Transaction, receipt *types.Receipt, contractAbi *abi.ABI) error {
+	res.Receipt = receipt
+	res.GasUsed, res.Status = receipt.GasUsed, receipt.Status
+	res.BlockHash = receipt.BlockHash
+	if receipt.BlockNumber != nil {
+		res.BlockNumber = receipt.BlockNumber.Uint64()
+	}
+	res.Events = DecodeEvents(contractAbi, receipt.Logs)
+
+	// the price of a dynamic fee transaction depends on the base fee of its block
+	if tx.Type() != types.DynamicFeeTxType {
+		res.EffectiveGasPrice = tx.GasPrice()
+		return nil
+	}
+	block, err := rpc.EthGetBlockByNumber(receipt.BlockNumber, false)
+	if err != nil {
+		return fmt.Errorf("get block of %s: %w", receipt.TxHash, err)
+	}
+	res.EffectiveGasPrice = effectiveGasPrice(tx, block.BaseFee())
+	return nil
+}
+
+// effectiveGasPrice returns the gas price tx pays in a block with baseFee
+func effectiveGasPrice(tx *types.Transaction, baseFee *big.Int) *big.Int {
+	if baseFee == nil {
+		return tx.GasPrice()
+	}
+	return new(big.Int).Add(baseFee, tx.EffectiveGasTipValue(baseFee))
+}
+
+// DecodeEvents decodes the logs emitted by the contract of contractAbi, the logs it can't decode, such as
+// those of other contracts or anonymous events, are kept with only Address and Log set
+func DecodeEvents(contractAbi *abi.ABI, logs []*types.Log) []*Event {
+	events := make([]*Event, 0, len(logs))
+	for _, entry := range logs {
+		event := &Event{Address: entry.Address, Log: entry}
+		events = append(events, event)
+		if contractAbi == nil || len(entry.Topics) == 0 {
+			continue
+		}
+		definition, err := contractAbi.EventByID(entry.Topics[0])
+		if err != nil {
+			continue
+		}
+		args := make(map[string]interface{})
+		var indexed abi.Arguments
+		for _, input := range definition.Inputs {
+			if input.Indexed {
+				indexed = append(indexed, input)
+			}
+		}
+		if err := abi.ParseTopicsIntoMap(args, indexed, entry.Topics[1:]); err != nil {
+			continue
+		}
+		if err := definition.Inputs.NonIndexed().UnpackIntoMap(args, entry.Data); err != nil {
+			continue
+		}
+		event.Name, event.Args = definition.Name, args
+	}
+	return events
 }
 
 func (rpc *EthRPC) EthGasPrice() (*big.Int, error) {
@@ -715,24 +1189,26 @@ func (rpc *EthRPC) EthGetBalance(account common.Address, blockNumber *big.Int) (
 }
 
 func (rpc *EthRPC) EthSendTransaction(privKey *ecdsa.PrivateKey, transaction *types.Transaction) (common.Hash, error) {
-	signTx, err := types.SignTx(transaction, types.NewEIP155Signer(rpc.cid), privKey)
-	if err != nil {
-		return common.Hash{}, err
-	}
-	if err := rpc.wrapper(func(ctx context.Context, client *clientConn) error {
-		err := client.conn.SendTransaction(ctx, signTx)
-		if err != nil {
-			return err
+	return rpc.EthSendTransactionBySigner(keySigner(privKey), transaction)
+}
+
+// EthSendTransactionBySigner signs transaction with txSigner, or with the default signer if txSigner is nil
+func (rpc *EthRPC) EthSendTransactionBySigner(txSigner signer.Signer, transaction *types.Transaction) (common.Hash, error) {
+	if txSigner == nil {
+		var err error
+		if txSigner, err = rpc.defaultSigner(); err != nil {
+			return common.Hash{}, err
 		}
-		return nil
-	}); err != nil {
+	}
+	signTx, err := txSigner.SignTx(transaction, rpc.cid)
+	if err != nil {
 		return common.Hash{}, err
 	}
-	return signTx.Hash(), nil
+	return rpc.EthSendRawTransaction(signTx)
 }
 
 func (rpc *EthRPC) EthSendRawTransaction(transaction *types.Transaction) (common.Hash, error) {
-	if err := rpc.wrapper(func(ctx context.Context, client *clientConn) error {
+	if err := rpc.sendWrapper(func(ctx context.Context, client *clientConn) error {
 		err := client.conn.SendTransaction(ctx, transaction)
 		if err != nil {
 			return err
@@ -745,7 +1221,19 @@ func (rpc *EthRPC) EthSendRawTransaction(transaction *types.Transaction) (common
 }
 
 func (rpc *EthRPC) EthSendTransactionWithReceipt(privKey *ecdsa.PrivateKey, transaction *types.Transaction) (*types.Receipt, error) {
-	hash, err := rpc.EthSendTransaction(privKey, transaction)
+	return rpc.EthSendTransactionWithReceiptBySigner(keySigner(privKey), transaction)
+}
+
+// keySigner returns the signer of privKey, it is nil if privKey is nil so that the default signer is used
+func keySigner(privKey *ecdsa.PrivateKey) signer.Signer {
+	if privKey == nil {
+		return nil
+	}
+	return signer.NewKeySigner(privKey)
+}
+
+func (rpc *EthRPC) EthSendTransactionWithReceiptBySigner(txSigner signer.Signer, transaction *types.Transaction) (*types.Receipt, error) {
+	hash, err := rpc.EthSendTransactionBySigner(txSigner, transaction)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,378 @@
+// Package rpcreplay records the JSON-RPC traffic between a client and a node into a
+// fixture file and serves it back later, so that tests recorded once against a real
+// cluster can run offline and deterministically.
+//
+// Both Recorder and Replayer are http.RoundTrippers and are plugged into the client
+// with an http.Client:
+//
+//	rec, _ := rpcreplay.NewRecorder("testdata/fixture.jsonl", nil)
+//	cli, _ := go_eth_client.New(go_eth_client.WithUrls(urls),
+//		go_eth_client.WithHTTPClient(&http.Client{Transport: rec}))
+//
+// Fixtures are JSON lines, one request and its response per line. Only http
+// endpoints are supported.
+package rpcreplay
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+)
+
+// Entry is one recorded request and its response
+type Entry struct {
+	Method   string          `json:"method"`
+	Params   json.RawMessage `json:"params,omitempty"`
+	Response json.RawMessage `json:"response"`
+}
+
+type message struct {
+	Id     json.RawMessage `json:"id,omitempty"`
+	Method string          `json:"method,omitempty"`
+	Params json.RawMessage `json:"params,omitempty"`
+}
+
+// Recorder forwards requests to the node and appends every exchange to a fixture file
+type Recorder struct {
+	next http.RoundTripper
+
+	mu   sync.Mutex
+	file *os.File
+}
+
+// NewRecorder creates the fixture file at path, an existing fixture is overwritten.
+// Requests are sent with next, or with http.DefaultTransport if next is nil.
+func NewRecorder(path string, next http.RoundTripper) (*Recorder, error) {
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, fmt.Errorf("create fixture: %w", err)
+	}
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return &Recorder{next: next, file: file}, nil
+}
+
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	body, err := readBody(req)
+	if err != nil {
+		return nil, err
+	}
+	req.Body = ioutil.NopCloser(bytes.NewReader(body))
+	resp, err := r.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	respBody, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = ioutil.NopCloser(bytes.NewReader(respBody))
+	// only answers of the node are recorded, http errors are left to the retries of the client
+	if resp.StatusCode != http.StatusOK {
+		return resp, nil
+	}
+
+	entries, err := pair(body, respBody)
+	if err != nil {
+		return nil, fmt.Errorf("record: %w", err)
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, entry := range entries {
+		line, err := json.Marshal(entry)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := r.file.Write(append(line, '\n')); err != nil {
+			return nil, fmt.Errorf("write fixture: %w", err)
+		}
+	}
+	return resp, nil
+}
+
+// Close closes the fixture file
+func (r *Recorder) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.file.Close()
+}
+
+// pair matches the requests in body with the responses in respBody by id
+func pair(body, respBody []byte) ([]Entry, error) {
+	reqs, _, err := decode(body)
+	if err != nil {
+		return nil, err
+	}
+	resps, _, err := decode(respBody)
+	if err != nil {
+		return nil, err
+	}
+	raws, err := split(respBody)
+	if err != nil {
+		return nil, err
+	}
+	responses := make(map[string]json.RawMessage, len(resps))
+	for i, resp := range resps {
+		responses[string(resp.Id)] = raws[i]
+	}
+	var entries []Entry
+	for _, req := range reqs {
+		resp, ok := responses[string(req.Id)]
+		if !ok {
+			// notifications have no response
+			continue
+		}
+		entries = append(entries, Entry{Method: req.Method, Params: req.Params, Response: resp})
+	}
+	return entries, nil
+}
+
+// Replayer answers requests with the responses of a fixture file
+type Replayer struct {
+	mu      sync.Mutex
+	entries []Entry
+	used    []bool
+}
+
+// NewReplayer loads the fixture file at path
+func NewReplayer(path string) (*Replayer, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("open fixture: %w", err)
+	}
+	defer file.Close()
+
+	r := &Replayer{}
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(nil, 64*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+		var entry Entry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			return nil, fmt.Errorf("fixture %s line %d: %w", path, line, err)
+		}
+		r.entries = append(r.entries, entry)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read fixture: %w", err)
+	}
+	r.used = make([]bool, len(r.entries))
+	return r, nil
+}
+
+func (r *Replayer) RoundTrip(req *http.Request) (*http.Response, error) {
+	body, err := readBody(req)
+	if err != nil {
+		return nil, err
+	}
+	msgs, batch, err := decode(body)
+	if err != nil {
+		return nil, fmt.Errorf("replay: %w", err)
+	}
+
+	var resps []json.RawMessage
+	for _, msg := range msgs {
+		resp, err := r.match(msg)
+		if err != nil {
+			return nil, err
+		}
+		if resp != nil {
+			resps = append(resps, resp)
+		}
+	}
+	var respBody []byte
+	if batch {
+		respBody, err = json.Marshal(resps)
+		if err != nil {
+			return nil, err
+		}
+	} else if len(resps) > 0 {
+		respBody = resps[0]
+	}
+	return &http.Response{
+		Status:        "200 OK",
+		StatusCode:    http.StatusOK,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        http.Header{"Content-Type": []string{"application/json"}},
+		Body:          ioutil.NopCloser(bytes.NewReader(respBody)),
+		ContentLength: int64(len(respBody)),
+		Request:       req,
+	}, nil
+}
+
+// Remaining returns the recorded entries which have not been replayed
+func (r *Replayer) Remaining() []Entry {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var entries []Entry
+	for i, entry := range r.entries {
+		if !r.used[i] {
+			entries = append(entries, entry)
+		}
+	}
+	return entries
+}
+
+// match returns the response of the first unused entry with the method and params of msg,
+// with the id of msg. Repeated requests are answered in recorded order.
+func (r *Replayer) match(msg message) (json.RawMessage, error) {
+	params, err := canonical(msg.Params)
+	if err != nil {
+		return nil, fmt.Errorf("replay: params of %s: %w", msg.Method, err)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var candidate *Entry
+	for i := range r.entries {
+		entry := &r.entries[i]
+		if r.used[i] || entry.Method != msg.Method {
+			continue
+		}
+		recorded, err := canonical(entry.Params)
+		if err != nil {
+			return nil, fmt.Errorf("replay: params of recorded %s: %w", entry.Method, err)
+		}
+		if recorded != params {
+			if candidate == nil {
+				candidate = entry
+			}
+			continue
+		}
+		r.used[i] = true
+		if msg.Id == nil {
+			return nil, nil
+		}
+		return withId(entry.Response, msg.Id)
+	}
+	return nil, mismatch(msg, candidate)
+}
+
+// mismatch describes an unexpected request, compared to the closest recorded one
+func mismatch(msg message, candidate *Entry) error {
+	if candidate == nil {
+		return fmt.Errorf("replay: unexpected request %s%s: no unused recorded request of this method",
+			msg.Method, string(msg.Params))
+	}
+	return fmt.Errorf("replay: unexpected request %s, params differ from the recording (-recorded +got):\n%s",
+		msg.Method, diff(indent(candidate.Params), indent(msg.Params)))
+}
+
+func withId(resp json.RawMessage, id json.RawMessage) (json.RawMessage, error) {
+	fields := make(map[string]json.RawMessage)
+	if err := json.Unmarshal(resp, &fields); err != nil {
+		return nil, fmt.Errorf("replay: recorded response: %w", err)
+	}
+	fields["id"] = id
+	return json.Marshal(fields)
+}
+
+func readBody(req *http.Request) ([]byte, error) {
+	if req.Body == nil {
+		return nil, nil
+	}
+	defer req.Body.Close()
+	return ioutil.ReadAll(req.Body)
+}
+
+// decode parses a single or batch JSON-RPC message
+func decode(body []byte) ([]message, bool, error) {
+	body = bytes.TrimSpace(body)
+	if len(body) > 0 && body[0] == '[' {
+		var msgs []message
+		if err := json.Unmarshal(body, &msgs); err != nil {
+			return nil, true, err
+		}
+		return msgs, true, nil
+	}
+	var msg message
+	if err := json.Unmarshal(body, &msg); err != nil {
+		return nil, false, err
+	}
+	return []message{msg}, false, nil
+}
+
+// split returns the raw messages of a single or batch JSON-RPC message
+func split(body []byte) ([]json.RawMessage, error) {
+	body = bytes.TrimSpace(body)
+	if len(body) > 0 && body[0] == '[' {
+		var raws []json.RawMessage
+		if err := json.Unmarshal(body, &raws); err != nil {
+			return nil, err
+		}
+		return raws, nil
+	}
+	return []json.RawMessage{body}, nil
+}
+
+// canonical returns params in a form that doesn't depend on formatting
+func canonical(params json.RawMessage) (string, error) {
+	if len(bytes.TrimSpace(params)) == 0 {
+		return "[]", nil
+	}
+	var v interface{}
+	if err := json.Unmarshal(params, &v); err != nil {
+		return "", err
+	}
+	if v == nil {
+		return "[]", nil
+	}
+	data, err := json.Marshal(v)
+	return string(data), err
+}
+
+func indent(params json.RawMessage) []string {
+	var buf bytes.Buffer
+	if err := json.Indent(&buf, params, "", "  "); err != nil {
+		return []string{string(params)}
+	}
+	return strings.Split(buf.String(), "\n")
+}
+
+// diff returns a line diff of a and b based on their longest common subsequence
+func diff(a, b []string) string {
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+	var sb strings.Builder
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			sb.WriteString("  " + a[i] + "\n")
+			i++
+			j++
+		case j == len(b) || (i < len(a) && lcs[i+1][j] >= lcs[i][j+1]):
+			sb.WriteString("- " + a[i] + "\n")
+			i++
+		default:
+			sb.WriteString("+ " + b[j] + "\n")
+			j++
+		}
+	}
+	return sb.String()
+}
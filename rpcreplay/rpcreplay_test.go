@@ -0,0 +1,104 @@
+package rpcreplay
+
+import (
+	"context"
+	"math/big"
+	"net/http"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/meshplus/go-eth-client/devchain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func dial(t *testing.T, url string, transport http.RoundTripper) *ethclient.Client {
+	cli, err := rpc.DialHTTPWithClient(url, &http.Client{Transport: transport})
+	require.Nil(t, err)
+	return ethclient.NewClient(cli)
+}
+
+func TestRecordReplay(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "fixture.jsonl")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	chain, err := devchain.Start(devchain.WithAccounts(1))
+	require.Nil(t, err)
+	address := crypto.PubkeyToAddress(chain.Accounts()[0].PublicKey)
+	recorder, err := NewRecorder(path, nil)
+	require.Nil(t, err)
+	cli := dial(t, chain.URLs()[0], recorder)
+	chainID, err := cli.ChainID(ctx)
+	require.Nil(t, err)
+	balance, err := cli.BalanceAt(ctx, address, nil)
+	require.Nil(t, err)
+	_, err = cli.BalanceAt(ctx, common.Address{0x1}, big.NewInt(0))
+	require.Nil(t, err)
+	cli.Close()
+	require.Nil(t, recorder.Close())
+	chain.Close()
+
+	// the node is gone, everything is answered from the fixture
+	replayer, err := NewReplayer(path)
+	require.Nil(t, err)
+	require.Equal(t, 3, len(replayer.Remaining()))
+	cli = dial(t, "http://replay", replayer)
+	defer cli.Close()
+	replayedID, err := cli.ChainID(ctx)
+	require.Nil(t, err)
+	assert.Equal(t, chainID, replayedID)
+	replayedBalance, err := cli.BalanceAt(ctx, address, nil)
+	require.Nil(t, err)
+	assert.Equal(t, balance, replayedBalance)
+
+	_, err = cli.BalanceAt(ctx, common.Address{0x2}, big.NewInt(0))
+	require.NotNil(t, err)
+	assert.Contains(t, err.Error(), "unexpected request eth_getBalance")
+	assert.Contains(t, err.Error(), `-   "0x0100000000000000000000000000000000000000"`)
+	assert.Contains(t, err.Error(), `+   "0x0200000000000000000000000000000000000000"`)
+	assert.Equal(t, 1, len(replayer.Remaining()))
+
+	// every recording is replayed once
+	_, err = cli.ChainID(ctx)
+	require.NotNil(t, err)
+	assert.Contains(t, err.Error(), "no unused recorded request")
+}
+
+func TestReplayBatch(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "fixture.jsonl")
+	chain, err := devchain.Start()
+	require.Nil(t, err)
+	recorder, err := NewRecorder(path, nil)
+	require.Nil(t, err)
+	cli, err := rpc.DialHTTPWithClient(chain.URLs()[0], &http.Client{Transport: recorder})
+	require.Nil(t, err)
+	var recorded [2]string
+	batch := []rpc.BatchElem{
+		{Method: "eth_chainId", Result: &recorded[0]},
+		{Method: "net_version", Result: &recorded[1]},
+	}
+	require.Nil(t, cli.BatchCall(batch))
+	cli.Close()
+	require.Nil(t, recorder.Close())
+	chain.Close()
+
+	replayer, err := NewReplayer(path)
+	require.Nil(t, err)
+	cli, err = rpc.DialHTTPWithClient("http://replay", &http.Client{Transport: replayer})
+	require.Nil(t, err)
+	defer cli.Close()
+	var replayed [2]string
+	batch = []rpc.BatchElem{
+		{Method: "net_version", Result: &replayed[1]},
+		{Method: "eth_chainId", Result: &replayed[0]},
+	}
+	require.Nil(t, cli.BatchCall(batch))
+	assert.Equal(t, recorded, replayed)
+	assert.Empty(t, replayer.Remaining())
+}
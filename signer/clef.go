@@ -0,0 +1,79 @@
+package signer
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/signer/core"
+)
+
+const defaultApprovalTimeout = 2 * time.Minute
+
+// ErrApprovalTimeout is returned when a request is neither approved nor rejected in time
+var ErrApprovalTimeout = errors.New("signing request was not approved in time")
+
+// RejectedError is returned when the user or the rules of the signer deny a request
+type RejectedError struct {
+	Method string
+	Reason string
+}
+
+func (e *RejectedError) Error() string {
+	return fmt.Sprintf("%s rejected by the signer: %s", e.Method, e.Reason)
+}
+
+var _ Signer = (*Clef)(nil)
+
+// Clef signs through the account_ API of a Clef-compatible external signer, every
+// request may wait for an approval on the signer side
+type Clef struct {
+	*remote
+}
+
+type ClefOption func(*Clef)
+
+// WithApprovalTimeout sets how long a request waits for the approval, 2 minutes by default
+func WithApprovalTimeout(t time.Duration) ClefOption {
+	return func(c *Clef) {
+		c.timeout = t
+	}
+}
+
+// NewClef connects to the signer at url, which is an http endpoint or the path of an ipc socket
+func NewClef(url string, address common.Address, opts ...ClefOption) (*Clef, error) {
+	r, err := dialRemote(url, address, defaultApprovalTimeout, ErrApprovalTimeout)
+	if err != nil {
+		return nil, fmt.Errorf("dial clef: %w", err)
+	}
+	c := &Clef{remote: r}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c, nil
+}
+
+func (c *Clef) SignTx(tx *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
+	return c.signTx("account_signTransaction", tx, chainID)
+}
+
+// SignHash is refused, clef only signs data it can show to the user
+func (c *Clef) SignHash(hash []byte) ([]byte, error) {
+	return nil, fmt.Errorf("sign hash: %w", ErrUnsupported)
+}
+
+// SignText signs data as an EIP-191 personal message, V is 27 or 28
+func (c *Clef) SignText(data []byte) ([]byte, error) {
+	address := common.NewMixedcaseAddress(c.address)
+	return c.sign("account_signData", accounts.MimetypeTextPlain, &address, hexutil.Encode(data))
+}
+
+func (c *Clef) SignTypedData(typedData core.TypedData) ([]byte, error) {
+	address := common.NewMixedcaseAddress(c.address)
+	return c.sign("account_signTypedData", &address, typedData)
+}
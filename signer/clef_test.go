@@ -0,0 +1,146 @@
+package signer
+
+import (
+	"errors"
+	"math/big"
+	"net"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/ethereum/go-ethereum/signer/core"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// clefService is a stand-in for clef, it approves, denies or never answers requests
+type clefService struct {
+	signService
+	deny  bool
+	delay time.Duration
+}
+
+func (s *clefService) approve() error {
+	time.Sleep(s.delay)
+	if s.deny {
+		return core.ErrRequestDenied
+	}
+	return nil
+}
+
+func (s *clefService) SignTransaction(args core.SendTxArgs) (map[string]interface{}, error) {
+	if err := s.approve(); err != nil {
+		return nil, err
+	}
+	return s.signService.SignTransaction(args)
+}
+
+func (s *clefService) SignData(contentType string, addr common.MixedcaseAddress, data string) (hexutil.Bytes, error) {
+	if err := s.approve(); err != nil {
+		return nil, err
+	}
+	if contentType != accounts.MimetypeTextPlain {
+		return nil, errors.New("unsupported content type")
+	}
+	text, err := hexutil.Decode(data)
+	if err != nil {
+		return nil, err
+	}
+	sig, err := s.signer.SignHash(accounts.TextHash(text))
+	if err != nil {
+		return nil, err
+	}
+	sig[crypto.RecoveryIDOffset] += 27
+	return sig, nil
+}
+
+func (s *clefService) SignTypedData(addr common.MixedcaseAddress, typedData core.TypedData) (hexutil.Bytes, error) {
+	if err := s.approve(); err != nil {
+		return nil, err
+	}
+	return s.signer.SignTypedData(typedData)
+}
+
+func startClef(t *testing.T, service *clefService) (httpUrl, ipcPath string) {
+	server := rpc.NewServer()
+	require.Nil(t, server.RegisterName("account", service))
+	t.Cleanup(server.Stop)
+	httpServer := httptest.NewServer(server)
+	t.Cleanup(httpServer.Close)
+
+	ipcPath = filepath.Join(t.TempDir(), "clef.ipc")
+	listener, err := net.Listen("unix", ipcPath)
+	require.Nil(t, err)
+	t.Cleanup(func() { listener.Close() })
+	go server.ServeListener(listener)
+	return httpServer.URL, ipcPath
+}
+
+func TestClef(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	require.Nil(t, err)
+	service := &clefService{signService: signService{signer: NewKeySigner(key)}}
+	httpUrl, ipcPath := startClef(t, service)
+	address := crypto.PubkeyToAddress(key.PublicKey)
+
+	for _, url := range []string{httpUrl, ipcPath} {
+		c, err := NewClef(url, address)
+		require.Nil(t, err)
+
+		chainID := big.NewInt(1356)
+		tx := types.NewTransaction(1, common.Address{0x1}, big.NewInt(1), 21000, big.NewInt(1), nil)
+		signed, err := c.SignTx(tx, chainID)
+		require.Nil(t, err)
+		sender, err := types.Sender(types.LatestSignerForChainID(chainID), signed)
+		require.Nil(t, err)
+		assert.Equal(t, address, sender)
+
+		sig, err := c.SignText([]byte("hello bitxhub"))
+		require.Nil(t, err)
+		sig[crypto.RecoveryIDOffset] -= 27
+		pub, err := crypto.SigToPub(accounts.TextHash([]byte("hello bitxhub")), sig)
+		require.Nil(t, err)
+		assert.Equal(t, address, crypto.PubkeyToAddress(*pub))
+
+		sig, err = c.SignTypedData(mailTypedData())
+		require.Nil(t, err)
+		expected, err := service.signer.SignTypedData(mailTypedData())
+		require.Nil(t, err)
+		assert.Equal(t, expected, sig)
+
+		_, err = c.SignHash(crypto.Keccak256(nil))
+		require.ErrorIs(t, err, ErrUnsupported)
+		c.Close()
+	}
+}
+
+func TestClefRejectAndTimeout(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	require.Nil(t, err)
+	service := &clefService{signService: signService{signer: NewKeySigner(key)}}
+	httpUrl, _ := startClef(t, service)
+	c, err := NewClef(httpUrl, crypto.PubkeyToAddress(key.PublicKey), WithApprovalTimeout(200*time.Millisecond))
+	require.Nil(t, err)
+	defer c.Close()
+	tx := types.NewTransaction(1, common.Address{0x1}, big.NewInt(1), 21000, big.NewInt(1), nil)
+
+	service.deny = true
+	_, err = c.SignTx(tx, big.NewInt(1356))
+	var rejected *RejectedError
+	require.True(t, errors.As(err, &rejected))
+	assert.Equal(t, "account_signTransaction", rejected.Method)
+	_, err = c.SignText([]byte("hello"))
+	require.True(t, errors.As(err, &rejected))
+
+	service.deny = false
+	service.delay = time.Second
+	_, err = c.SignTx(tx, big.NewInt(1356))
+	require.ErrorIs(t, err, ErrApprovalTimeout)
+}
@@ -0,0 +1,45 @@
+package signer
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/signer/core"
+)
+
+const defaultExternalTimeout = 30 * time.Second
+
+var _ Signer = (*External)(nil)
+
+// External signs through the eth_signTransaction and eth_signTypedData_v4 methods of a
+// remote signing service, such as a node with an unlocked account or Web3Signer.
+// The key never enters the process.
+type External struct {
+	*remote
+}
+
+// NewExternal connects to the signing service at url, which signs for address
+func NewExternal(url string, address common.Address) (*External, error) {
+	r, err := dialRemote(url, address, defaultExternalTimeout, context.DeadlineExceeded)
+	if err != nil {
+		return nil, fmt.Errorf("dial external signer: %w", err)
+	}
+	return &External{remote: r}, nil
+}
+
+func (s *External) SignTx(tx *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
+	return s.signTx("eth_signTransaction", tx, chainID)
+}
+
+// SignHash is refused, remote signers only sign data they can show to the user
+func (s *External) SignHash(hash []byte) ([]byte, error) {
+	return nil, fmt.Errorf("sign hash: %w", ErrUnsupported)
+}
+
+func (s *External) SignTypedData(typedData core.TypedData) ([]byte, error) {
+	return s.sign("eth_signTypedData_v4", s.address, typedData)
+}
@@ -0,0 +1,150 @@
+package signer
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/signer/core"
+)
+
+// TextSigner is implemented by signers which sign EIP-191 personal messages themselves, such as Clef
+type TextSigner interface {
+	SignText(data []byte) ([]byte, error)
+}
+
+// TextHash returns the EIP-191 hash of a personal message:
+// keccak256("\x19Ethereum Signed Message:\n" + len(data) + data)
+func TextHash(data []byte) []byte {
+	return accounts.TextHash(data)
+}
+
+// SignText signs data like personal_sign, V of the signature is 27 or 28
+func SignText(s Signer, data []byte) ([]byte, error) {
+	if textSigner, ok := s.(TextSigner); ok {
+		return textSigner.SignText(data)
+	}
+	sig, err := s.SignHash(TextHash(data))
+	if err != nil {
+		return nil, err
+	}
+	return WithEthereumV(sig)
+}
+
+// ParseTypedData parses the json taken by eth_signTypedData_v4, with types, primaryType, domain and message
+func ParseTypedData(data []byte) (core.TypedData, error) {
+	var typedData core.TypedData
+	if err := json.Unmarshal(data, &typedData); err != nil {
+		return core.TypedData{}, fmt.Errorf("parse typed data: %w", err)
+	}
+	if _, ok := typedData.Types["EIP712Domain"]; !ok {
+		return core.TypedData{}, fmt.Errorf("parse typed data: EIP712Domain type is missing")
+	}
+	if _, ok := typedData.Types[typedData.PrimaryType]; !ok {
+		return core.TypedData{}, fmt.Errorf("parse typed data: primary type %q is not defined", typedData.PrimaryType)
+	}
+	return typedData, nil
+}
+
+// SignTypedDataJSON signs the typed data described by the json taken by eth_signTypedData_v4
+func SignTypedDataJSON(s Signer, data []byte) ([]byte, error) {
+	typedData, err := ParseTypedData(data)
+	if err != nil {
+		return nil, err
+	}
+	return s.SignTypedData(typedData)
+}
+
+// SplitSignature splits a [R || S || V] signature into the v, r and s taken by Solidity's ecrecover,
+// v is 27 or 28 whether V of sig is 0, 1, 27 or 28
+func SplitSignature(sig []byte) (v uint8, r, s [32]byte, err error) {
+	normalized, err := WithEthereumV(sig)
+	if err != nil {
+		return 0, r, s, err
+	}
+	copy(r[:], normalized[:32])
+	copy(s[:], normalized[32:64])
+	return normalized[crypto.RecoveryIDOffset], r, s, nil
+}
+
+// WithEthereumV returns a copy of sig with V 27 or 28, as personal_sign and ecrecover use
+func WithEthereumV(sig []byte) ([]byte, error) {
+	id, err := recoveryID(sig)
+	if err != nil {
+		return nil, err
+	}
+	normalized := common.CopyBytes(sig)
+	normalized[crypto.RecoveryIDOffset] = id + 27
+	return normalized, nil
+}
+
+// WithRecoveryID returns a copy of sig with V 0 or 1, as crypto.Sign produces and as Broker's
+// splitSignature expects before adding 27
+func WithRecoveryID(sig []byte) ([]byte, error) {
+	id, err := recoveryID(sig)
+	if err != nil {
+		return nil, err
+	}
+	normalized := common.CopyBytes(sig)
+	normalized[crypto.RecoveryIDOffset] = id
+	return normalized, nil
+}
+
+// Ecrecover returns the address which signed hash, with the checks of Solidity's ecrecover:
+// r and s must be in [1, n-1] while high s values are accepted
+func Ecrecover(hash, sig []byte) (common.Address, error) {
+	if len(hash) != 32 {
+		return common.Address{}, fmt.Errorf("hash must be 32 bytes, got %d", len(hash))
+	}
+	normalized, err := WithRecoveryID(sig)
+	if err != nil {
+		return common.Address{}, err
+	}
+	r := new(big.Int).SetBytes(normalized[:32])
+	s := new(big.Int).SetBytes(normalized[32:64])
+	if !crypto.ValidateSignatureValues(normalized[crypto.RecoveryIDOffset], r, s, false) {
+		return common.Address{}, fmt.Errorf("invalid signature values")
+	}
+	pub, err := crypto.Ecrecover(hash, normalized)
+	if err != nil {
+		return common.Address{}, err
+	}
+	return common.BytesToAddress(crypto.Keccak256(pub[1:])[12:]), nil
+}
+
+// Verify reports whether sig is the signature of hash by address
+func Verify(address common.Address, hash, sig []byte) bool {
+	recovered, err := Ecrecover(hash, sig)
+	return err == nil && recovered == address
+}
+
+// RecoverText returns the address which signed the personal message data
+func RecoverText(data, sig []byte) (common.Address, error) {
+	return Ecrecover(TextHash(data), sig)
+}
+
+// RecoverTypedData returns the address which signed typedData
+func RecoverTypedData(typedData core.TypedData, sig []byte) (common.Address, error) {
+	hash, err := TypedDataHash(typedData)
+	if err != nil {
+		return common.Address{}, err
+	}
+	return Ecrecover(hash, sig)
+}
+
+func recoveryID(sig []byte) (byte, error) {
+	if len(sig) != crypto.SignatureLength {
+		return 0, fmt.Errorf("signature must be %d bytes, got %d", crypto.SignatureLength, len(sig))
+	}
+	switch v := sig[crypto.RecoveryIDOffset]; v {
+	case 0, 1:
+		return v, nil
+	case 27, 28:
+		return v - 27, nil
+	default:
+		return 0, fmt.Errorf("invalid signature v %d", v)
+	}
+}
@@ -0,0 +1,103 @@
+package signer
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind/backends"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// solidityEcrecover calls the ecrecover precompile of the EVM
+func solidityEcrecover(t *testing.T, backend *backends.SimulatedBackend, hash, sig []byte) common.Address {
+	v, r, s, err := SplitSignature(sig)
+	require.Nil(t, err)
+	input := append(common.CopyBytes(hash), common.LeftPadBytes([]byte{v}, 32)...)
+	input = append(append(input, r[:]...), s[:]...)
+	precompile := common.BytesToAddress([]byte{1})
+	output, err := backend.CallContract(context.Background(), ethereum.CallMsg{To: &precompile, Data: input}, nil)
+	require.Nil(t, err)
+	return common.BytesToAddress(output)
+}
+
+func TestEcrecover(t *testing.T) {
+	backend := backends.NewSimulatedBackend(core.GenesisAlloc{}, 10000000)
+	defer backend.Close()
+	key, err := crypto.GenerateKey()
+	require.Nil(t, err)
+	s := NewKeySigner(key)
+
+	hash := crypto.Keccak256([]byte("interchain"))
+	sig, err := s.SignHash(hash)
+	require.Nil(t, err)
+	assert.True(t, sig[crypto.RecoveryIDOffset] < 2)
+	assert.Equal(t, s.Address(), solidityEcrecover(t, backend, hash, sig))
+	assert.True(t, Verify(s.Address(), hash, sig))
+
+	ethSig, err := WithEthereumV(sig)
+	require.Nil(t, err)
+	assert.Equal(t, sig[crypto.RecoveryIDOffset]+27, ethSig[crypto.RecoveryIDOffset])
+	assert.True(t, Verify(s.Address(), hash, ethSig))
+	rawSig, err := WithRecoveryID(ethSig)
+	require.Nil(t, err)
+	assert.Equal(t, sig, rawSig)
+
+	// ecrecover accepts the malleable signature with high s, as the precompile does
+	n := crypto.S256().Params().N
+	highS := new(big.Int).Sub(n, new(big.Int).SetBytes(sig[32:64]))
+	malleable := append(common.CopyBytes(sig[:32]), common.LeftPadBytes(highS.Bytes(), 32)...)
+	malleable = append(malleable, sig[crypto.RecoveryIDOffset]^1)
+	assert.Equal(t, s.Address(), solidityEcrecover(t, backend, hash, malleable))
+	assert.True(t, Verify(s.Address(), hash, malleable))
+
+	bad := common.CopyBytes(sig)
+	bad[crypto.RecoveryIDOffset] = 2
+	_, err = Ecrecover(hash, bad)
+	require.NotNil(t, err)
+	_, err = Ecrecover(hash, sig[:64])
+	require.NotNil(t, err)
+	assert.False(t, Verify(common.Address{0x1}, hash, sig))
+}
+
+func TestSignText(t *testing.T) {
+	key := crypto.ToECDSAUnsafe(crypto.Keccak256([]byte("cow")))
+	s := NewKeySigner(key)
+
+	sig, err := SignText(s, []byte("hello bitxhub"))
+	require.Nil(t, err)
+	assert.Contains(t, []byte{27, 28}, sig[crypto.RecoveryIDOffset])
+	address, err := RecoverText([]byte("hello bitxhub"), sig)
+	require.Nil(t, err)
+	assert.Equal(t, s.Address(), address)
+	address, err = RecoverText([]byte("hello"), sig)
+	require.Nil(t, err)
+	assert.NotEqual(t, s.Address(), address)
+}
+
+func TestSignTypedDataJSON(t *testing.T) {
+	key := crypto.ToECDSAUnsafe(crypto.Keccak256([]byte("cow")))
+	s := NewKeySigner(key)
+	data, err := json.Marshal(mailTypedData())
+	require.Nil(t, err)
+
+	sig, err := SignTypedDataJSON(s, data)
+	require.Nil(t, err)
+	expected, err := s.SignTypedData(mailTypedData())
+	require.Nil(t, err)
+	assert.Equal(t, expected, sig)
+	address, err := RecoverTypedData(mailTypedData(), sig)
+	require.Nil(t, err)
+	assert.Equal(t, s.Address(), address)
+
+	_, err = ParseTypedData([]byte(`{"types":{"EIP712Domain":[]},"primaryType":"Mail","domain":{},"message":{}}`))
+	require.NotNil(t, err)
+	_, err = ParseTypedData([]byte(`{"types":{},"primaryType":"Mail"}`))
+	require.NotNil(t, err)
+}
@@ -0,0 +1,160 @@
+package signer
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/ethereum/go-ethereum/signer/core"
+)
+
+// remote is the json-rpc plumbing shared by the signers which sign through a signing service
+type remote struct {
+	client     *rpc.Client
+	address    common.Address
+	timeout    time.Duration // 单个请求的超时时间
+	timeoutErr error         // 请求超时时返回的错误
+}
+
+// dialRemote connects to the signing service at url, which signs for address
+func dialRemote(url string, address common.Address, timeout time.Duration, timeoutErr error) (*remote, error) {
+	client, err := rpc.Dial(url)
+	if err != nil {
+		return nil, err
+	}
+	return &remote{
+		client:     client,
+		address:    address,
+		timeout:    timeout,
+		timeoutErr: timeoutErr,
+	}, nil
+}
+
+func (r *remote) Address() common.Address {
+	return r.address
+}
+
+// Close closes the connection to the signing service
+func (r *remote) Close() {
+	r.client.Close()
+}
+
+// call sends a request to the service and converts denials and timeouts to typed errors
+func (r *remote) call(result interface{}, method string, args ...interface{}) error {
+	ctx, cancel := context.WithTimeout(context.Background(), r.timeout)
+	defer cancel()
+	err := r.client.CallContext(ctx, result, method, args...)
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, context.DeadlineExceeded) || errors.Is(ctx.Err(), context.DeadlineExceeded):
+		return fmt.Errorf("%s: %w", method, r.timeoutErr)
+	case strings.Contains(err.Error(), core.ErrRequestDenied.Error()):
+		return &RejectedError{Method: method, Reason: err.Error()}
+	default:
+		return fmt.Errorf("%s: %w", method, err)
+	}
+}
+
+// signTx requests method to sign tx and checks the signed transaction returned
+func (r *remote) signTx(method string, tx *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
+	args := txArgs(r.address, tx, chainID)
+	var result json.RawMessage
+	if err := r.call(&result, method, &args); err != nil {
+		return nil, err
+	}
+	signed, err := decodeSignedTx(result)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkSignedTx(r.address, tx, signed, chainID); err != nil {
+		return nil, err
+	}
+	return signed, nil
+}
+
+// sign requests method to sign and returns the signature with V 27 or 28, whichever the service uses
+func (r *remote) sign(method string, args ...interface{}) ([]byte, error) {
+	var sig hexutil.Bytes
+	if err := r.call(&sig, method, args...); err != nil {
+		return nil, err
+	}
+	normalized, err := WithEthereumV(sig)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", method, err)
+	}
+	return normalized, nil
+}
+
+// txArgs converts tx to the arguments of a signing request
+func txArgs(from common.Address, tx *types.Transaction, chainID *big.Int) core.SendTxArgs {
+	args := core.SendTxArgs{
+		From:    common.NewMixedcaseAddress(from),
+		Gas:     hexutil.Uint64(tx.Gas()),
+		Value:   hexutil.Big(*tx.Value()),
+		Nonce:   hexutil.Uint64(tx.Nonce()),
+		ChainID: (*hexutil.Big)(chainID),
+	}
+	if tx.To() != nil {
+		to := common.NewMixedcaseAddress(*tx.To())
+		args.To = &to
+	}
+	data := hexutil.Bytes(tx.Data())
+	args.Data = &data
+	if tx.Type() == types.DynamicFeeTxType {
+		args.MaxFeePerGas = (*hexutil.Big)(tx.GasFeeCap())
+		args.MaxPriorityFeePerGas = (*hexutil.Big)(tx.GasTipCap())
+	} else {
+		args.GasPrice = (*hexutil.Big)(tx.GasPrice())
+	}
+	if tx.Type() != types.LegacyTxType {
+		accessList := tx.AccessList()
+		args.AccessList = &accessList
+	}
+	return args
+}
+
+// decodeSignedTx accepts both the raw transaction and the {raw, tx} object returned by geth
+func decodeSignedTx(result json.RawMessage) (*types.Transaction, error) {
+	var raw hexutil.Bytes
+	if err := json.Unmarshal(result, &raw); err != nil {
+		var obj struct {
+			Raw hexutil.Bytes `json:"raw"`
+		}
+		if err := json.Unmarshal(result, &obj); err != nil {
+			return nil, fmt.Errorf("decode signed transaction: %w", err)
+		}
+		raw = obj.Raw
+	}
+	tx := new(types.Transaction)
+	if err := tx.UnmarshalBinary(raw); err != nil {
+		return nil, fmt.Errorf("decode signed transaction: %w", err)
+	}
+	return tx, nil
+}
+
+// checkSignedTx verifies that signed is tx signed by from, a remote signer may not alter the transaction
+func checkSignedTx(from common.Address, tx, signed *types.Transaction, chainID *big.Int) error {
+	sender, err := types.Sender(types.LatestSignerForChainID(chainID), signed)
+	if err != nil {
+		return fmt.Errorf("recover sender: %w", err)
+	}
+	if sender != from {
+		return fmt.Errorf("transaction is signed by %s, expected %s", sender, from)
+	}
+	if signed.Nonce() != tx.Nonce() || signed.Gas() != tx.Gas() || signed.Value().Cmp(tx.Value()) != 0 ||
+		signed.GasFeeCap().Cmp(tx.GasFeeCap()) != 0 || signed.GasTipCap().Cmp(tx.GasTipCap()) != 0 ||
+		(signed.To() == nil) != (tx.To() == nil) || (tx.To() != nil && *signed.To() != *tx.To()) ||
+		string(signed.Data()) != string(tx.Data()) {
+		return fmt.Errorf("signed transaction differs from the request")
+	}
+	return nil
+}
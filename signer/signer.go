@@ -0,0 +1,48 @@
+// Package signer abstracts the signing of transactions and data from where the key
+// lives: in memory, in a keystore file or behind an external signing service.
+package signer
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/signer/core"
+)
+
+// ErrUnsupported is returned by signers which refuse an operation, such as
+// signing a raw hash with a remote signer
+var ErrUnsupported = errors.New("operation is not supported by the signer")
+
+// Signer signs for one account. Signatures are 65 bytes in the [R || S || V] format, and the
+// convention of V is fixed per method whatever the implementation, remote signers included:
+//   - SignHash returns V as the recovery id 0 or 1, like crypto.Sign, as the hash may be signed
+//     for any scheme. WithEthereumV converts it for ecrecover.
+//   - SignTypedData, like SignText, returns V as 27 or 28, like eth_signTypedData_v4, so that
+//     the signature is taken by Solidity's ecrecover as it is.
+type Signer interface {
+	// Address returns the address of the account
+	Address() common.Address
+	// SignTx returns tx signed for the chain chainID
+	SignTx(tx *types.Transaction, chainID *big.Int) (*types.Transaction, error)
+	// SignHash signs a 32 bytes hash, V of the signature is 0 or 1
+	SignHash(hash []byte) ([]byte, error)
+	// SignTypedData signs EIP-712 typed data, V of the signature is 27 or 28
+	SignTypedData(typedData core.TypedData) ([]byte, error)
+}
+
+// TypedDataHash returns the EIP-712 hash of typedData
+func TypedDataHash(typedData core.TypedData) ([]byte, error) {
+	domainSeparator, err := typedData.HashStruct("EIP712Domain", typedData.Domain.Map())
+	if err != nil {
+		return nil, fmt.Errorf("hash domain: %w", err)
+	}
+	hash, err := typedData.HashStruct(typedData.PrimaryType, typedData.Message)
+	if err != nil {
+		return nil, fmt.Errorf("hash %s: %w", typedData.PrimaryType, err)
+	}
+	return crypto.Keccak256([]byte{0x19, 0x01}, domainSeparator, hash), nil
+}
@@ -0,0 +1,183 @@
+package signer
+
+import (
+	"io/ioutil"
+	"math/big"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/ethereum/go-ethereum/signer/core"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// mailTypedData is the example of EIP-712
+func mailTypedData() core.TypedData {
+	return core.TypedData{
+		Types: core.Types{
+			"EIP712Domain": {
+				{Name: "name", Type: "string"},
+				{Name: "version", Type: "string"},
+				{Name: "chainId", Type: "uint256"},
+				{Name: "verifyingContract", Type: "address"},
+			},
+			"Person": {
+				{Name: "name", Type: "string"},
+				{Name: "wallet", Type: "address"},
+			},
+			"Mail": {
+				{Name: "from", Type: "Person"},
+				{Name: "to", Type: "Person"},
+				{Name: "contents", Type: "string"},
+			},
+		},
+		PrimaryType: "Mail",
+		Domain: core.TypedDataDomain{
+			Name:              "Ether Mail",
+			Version:           "1",
+			ChainId:           math.NewHexOrDecimal256(1),
+			VerifyingContract: "0xCcCCccccCCCCcCCCCCCcCcCccCcCCCcCcccccccC",
+		},
+		Message: core.TypedDataMessage{
+			"from": map[string]interface{}{
+				"name":   "Cow",
+				"wallet": "0xCD2a3d9F938E13CD947Ec05AbC7FE734Df8DD826",
+			},
+			"to": map[string]interface{}{
+				"name":   "Bob",
+				"wallet": "0xbBbBBBBbbBBBbbbBbbBbbbbBBbBbbbbBbBbbBBbB",
+			},
+			"contents": "Hello, Bob!",
+		},
+	}
+}
+
+func TestKeySigner(t *testing.T) {
+	key := crypto.ToECDSAUnsafe(crypto.Keccak256([]byte("cow")))
+	s := NewKeySigner(key)
+	require.Equal(t, common.HexToAddress("0xCD2a3d9F938E13CD947Ec05AbC7FE734Df8DD826"), s.Address())
+
+	chainID := big.NewInt(1356)
+	tx := types.NewTransaction(1, common.Address{0x1}, big.NewInt(1), 21000, big.NewInt(1), nil)
+	signed, err := s.SignTx(tx, chainID)
+	require.Nil(t, err)
+	require.Nil(t, checkSignedTx(s.Address(), tx, signed, chainID))
+
+	hash := crypto.Keccak256([]byte("hash"))
+	sig, err := s.SignHash(hash)
+	require.Nil(t, err)
+	pub, err := crypto.SigToPub(hash, sig)
+	require.Nil(t, err)
+	assert.Equal(t, s.Address(), crypto.PubkeyToAddress(*pub))
+
+	typedHash, err := TypedDataHash(mailTypedData())
+	require.Nil(t, err)
+	assert.Equal(t, "0xbe609aee343fb3c4b28e1df9e632fca64fcfaede20f02e86244efddf30957bd2", hexutil.Encode(typedHash))
+	sig, err = s.SignTypedData(mailTypedData())
+	require.Nil(t, err)
+	assert.Equal(t, "0x4355c47d63924e8a72e509b65029052eb6c299d53a04e167c5775fd466751c9d"+
+		"07299936d304c153f6443dfa05f40ff007d72911b6f72307f996231605b91562"+"1c", hexutil.Encode(sig))
+}
+
+func TestKeystoreSigner(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	require.Nil(t, err)
+	keyJson, err := keystore.EncryptKey(&keystore.Key{
+		Id:         uuid.New(),
+		Address:    crypto.PubkeyToAddress(key.PublicKey),
+		PrivateKey: key,
+	}, "bitxhub", keystore.LightScryptN, keystore.LightScryptP)
+	require.Nil(t, err)
+	path := filepath.Join(t.TempDir(), "key.json")
+	require.Nil(t, ioutil.WriteFile(path, keyJson, 0600))
+
+	s, err := NewKeystoreSigner(path, "bitxhub")
+	require.Nil(t, err)
+	assert.Equal(t, crypto.PubkeyToAddress(key.PublicKey), s.Address())
+	_, err = NewKeystoreSigner(path, "wrong")
+	require.NotNil(t, err)
+}
+
+// signService is a stand-in for a remote signing service
+type signService struct {
+	signer     *KeySigner
+	tamper     bool
+	recoveryID bool // 返回V为0或1的签名
+}
+
+func (s *signService) SignTransaction(args core.SendTxArgs) (map[string]interface{}, error) {
+	to := args.To.Address()
+	if s.tamper {
+		to = common.Address{}
+	}
+	tx := types.NewTransaction(uint64(args.Nonce), to, args.Value.ToInt(), uint64(args.Gas), args.GasPrice.ToInt(), *args.Data)
+	signed, err := s.signer.SignTx(tx, args.ChainID.ToInt())
+	if err != nil {
+		return nil, err
+	}
+	raw, err := signed.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{"raw": hexutil.Bytes(raw), "tx": signed}, nil
+}
+
+func (s *signService) SignTypedData_v4(address common.Address, typedData core.TypedData) (hexutil.Bytes, error) {
+	sig, err := s.signer.SignTypedData(typedData)
+	if err != nil || !s.recoveryID {
+		return sig, err
+	}
+	return WithRecoveryID(sig)
+}
+
+func TestExternalSigner(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	require.Nil(t, err)
+	service := &signService{signer: NewKeySigner(key)}
+	server := rpc.NewServer()
+	require.Nil(t, server.RegisterName("eth", service))
+	defer server.Stop()
+	httpServer := httptest.NewServer(server)
+	defer httpServer.Close()
+
+	s, err := NewExternal(httpServer.URL, crypto.PubkeyToAddress(key.PublicKey))
+	require.Nil(t, err)
+	defer s.Close()
+
+	chainID := big.NewInt(1356)
+	tx := types.NewTransaction(1, common.Address{0x1}, big.NewInt(1), 21000, big.NewInt(1), []byte{0x1})
+	signed, err := s.SignTx(tx, chainID)
+	require.Nil(t, err)
+	sender, err := types.Sender(types.LatestSignerForChainID(chainID), signed)
+	require.Nil(t, err)
+	assert.Equal(t, s.Address(), sender)
+	assert.Equal(t, tx.Nonce(), signed.Nonce())
+
+	sig, err := s.SignTypedData(mailTypedData())
+	require.Nil(t, err)
+	expected, err := service.signer.SignTypedData(mailTypedData())
+	require.Nil(t, err)
+	assert.Equal(t, expected, sig)
+	// V is 27 or 28 whatever the service returns
+	service.recoveryID = true
+	sig, err = s.SignTypedData(mailTypedData())
+	require.Nil(t, err)
+	assert.Equal(t, expected, sig)
+
+	_, err = s.SignHash(crypto.Keccak256(nil))
+	require.ErrorIs(t, err, ErrUnsupported)
+
+	// a signer returning another transaction than requested is not trusted
+	service.tamper = true
+	_, err = s.SignTx(tx, chainID)
+	require.NotNil(t, err)
+}
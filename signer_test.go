@@ -0,0 +1,211 @@
+package go_eth_client
+
+import (
+	"io/ioutil"
+	"math/big"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	ethrpc "github.com/ethereum/go-ethereum/rpc"
+	signercore "github.com/ethereum/go-ethereum/signer/core"
+	"github.com/meshplus/go-eth-client/config"
+	"github.com/meshplus/go-eth-client/signer"
+	"github.com/meshplus/go-eth-client/utils"
+	"github.com/stretchr/testify/require"
+)
+
+// signService is a stand-in for a remote signing service holding the key
+type signService struct {
+	signer *signer.KeySigner
+}
+
+func (s *signService) SignTransaction(args signercore.SendTxArgs) (hexutil.Bytes, error) {
+	var to *common.Address
+	if args.To != nil {
+		address := args.To.Address()
+		to = &address
+	}
+	var tx *types.Transaction
+	if args.MaxFeePerGas != nil {
+		tx = types.NewTx(&types.DynamicFeeTx{
+			ChainID:   args.ChainID.ToInt(),
+			Nonce:     uint64(args.Nonce),
+			GasTipCap: args.MaxPriorityFeePerGas.ToInt(),
+			GasFeeCap: args.MaxFeePerGas.ToInt(),
+			Gas:       uint64(args.Gas),
+			To:        to,
+			Value:     args.Value.ToInt(),
+			Data:      *args.Data,
+		})
+	} else {
+		tx = types.NewTx(&types.LegacyTx{
+			Nonce:    uint64(args.Nonce),
+			GasPrice: args.GasPrice.ToInt(),
+			Gas:      uint64(args.Gas),
+			To:       to,
+			Value:    args.Value.ToInt(),
+			Data:     *args.Data,
+		})
+	}
+	signed, err := s.signer.SignTx(tx, args.ChainID.ToInt())
+	if err != nil {
+		return nil, err
+	}
+	return signed.MarshalBinary()
+}
+
+func TestSignerDeployAndInvoke(t *testing.T) {
+	sim, _ := newSimulated(t)
+	defer sim.Stop()
+	pk := sim.privateKey
+
+	server := ethrpc.NewServer()
+	require.Nil(t, server.RegisterName("eth", &signService{signer: signer.NewKeySigner(pk)}))
+	defer server.Stop()
+	httpServer := httptest.NewServer(server)
+	defer httpServer.Close()
+	external, err := signer.NewExternal(httpServer.URL, crypto.PubkeyToAddress(pk.PublicKey))
+	require.Nil(t, err)
+	defer external.Close()
+
+	contractAbi, err := utils.LoadAbi("./testdata/storage.abi")
+	require.Nil(t, err)
+	code, err := ioutil.ReadFile("./testdata/storage.bin")
+	require.Nil(t, err)
+	address, _, err := sim.DeployByCode(nil, contractAbi, string(code), nil, WithSigner(external))
+	require.Nil(t, err)
+
+	args, err := utils.Decode(&contractAbi, "store", "7")
+	require.Nil(t, err)
+	res, err := sim.InvokeWithReceipt(nil, &contractAbi, address, "store", args, WithSigner(external))
+	require.Nil(t, err)
+	require.Equal(t, types.ReceiptStatusSuccessful, res[0].(*types.Receipt).Status)
+	callRes, err := sim.EthCall(&contractAbi, address, "retrieve", nil)
+	require.Nil(t, err)
+	require.Equal(t, "7", callRes[0].(*big.Int).String())
+
+	price, err := sim.EthGasPrice()
+	require.Nil(t, err)
+	nonce, err := sim.EthGetTransactionCount(external.Address(), nil)
+	require.Nil(t, err)
+	receipt, err := sim.EthSendTransactionWithReceiptBySigner(external,
+		utils.NewTransaction(nonce, external.Address(), 21000, price, nil, big.NewInt(1)))
+	require.Nil(t, err)
+	require.Equal(t, types.ReceiptStatusSuccessful, receipt.Status)
+}
+
+func TestHDWalletSigner(t *testing.T) {
+	wallet, err := utils.NewHDWallet("test test test test test test test test test test test junk", "")
+	require.Nil(t, err)
+	account, err := wallet.Signer("m/44'/60'/0'/0/0")
+	require.Nil(t, err)
+	sim, err := NewSimulated(core.GenesisAlloc{
+		account.Address(): {Balance: new(big.Int).Exp(big.NewInt(10), big.NewInt(30), nil)},
+	})
+	require.Nil(t, err)
+	defer sim.Stop()
+
+	contractAbi, err := utils.LoadAbi("./testdata/storage.abi")
+	require.Nil(t, err)
+	code, err := ioutil.ReadFile("./testdata/storage.bin")
+	require.Nil(t, err)
+	address, _, err := sim.DeployByCode(nil, contractAbi, string(code), nil, WithSigner(account))
+	require.Nil(t, err)
+	args, err := utils.Decode(&contractAbi, "store", "9")
+	require.Nil(t, err)
+	res, err := sim.InvokeWithReceipt(nil, &contractAbi, address, "store", args, WithSigner(account))
+	require.Nil(t, err)
+	require.Equal(t, types.ReceiptStatusSuccessful, res[0].(*types.Receipt).Status)
+}
+
+func TestDefaultSigner(t *testing.T) {
+	sim, _ := newSimulated(t)
+	defer sim.Stop()
+	pk := sim.privateKey
+	require.Equal(t, crypto.PubkeyToAddress(pk.PublicKey), sim.DefaultSigner().Address())
+
+	// the key of WithPriKey signs when no key is given
+	contractAbi, err := utils.LoadAbi("./testdata/storage.abi")
+	require.Nil(t, err)
+	code, err := ioutil.ReadFile("./testdata/storage.bin")
+	require.Nil(t, err)
+	address, _, err := sim.DeployByCode(nil, contractAbi, string(code), nil)
+	require.Nil(t, err)
+	args, err := utils.Decode(&contractAbi, "store", "3")
+	require.Nil(t, err)
+	res, err := sim.InvokeWithReceipt(nil, &contractAbi, address, "store", args)
+	require.Nil(t, err)
+	require.Equal(t, types.ReceiptStatusSuccessful, res[0].(*types.Receipt).Status)
+
+	price, err := sim.EthGasPrice()
+	require.Nil(t, err)
+	to := common.HexToAddress("0x47bd692d7728dee508a2791701d54597cc1b8100")
+	receipt, err := sim.EthSendTransactionWithReceipt(nil, utils.NewTransaction(2, to, 21000, price, nil, big.NewInt(1)))
+	require.Nil(t, err)
+	require.Equal(t, types.ReceiptStatusSuccessful, receipt.Status)
+	balance, err := sim.EthGetBalance(to, nil)
+	require.Nil(t, err)
+	require.Equal(t, "1", balance.String())
+
+	// without any key only read-only methods can be invoked
+	other, err := NewSimulated(core.GenesisAlloc{})
+	require.Nil(t, err)
+	defer other.Stop()
+	require.Nil(t, other.DefaultSigner())
+	_, err = other.Invoke(nil, &contractAbi, address, "store", args)
+	require.NotNil(t, err)
+	_, err = other.EthSendTransaction(nil, utils.NewTransaction(0, to, 21000, price, nil, big.NewInt(1)))
+	require.NotNil(t, err)
+}
+
+type callService struct {
+	from atomic.Value
+}
+
+func (s *callService) ChainId() hexutil.Uint64 {
+	return 1356
+}
+
+func (s *callService) Call(args struct {
+	From common.Address `json:"from"`
+}, block string) hexutil.Bytes {
+	s.from.Store(args.From)
+	return common.LeftPadBytes([]byte{5}, 32)
+}
+
+func TestDefaultSignerFromConfig(t *testing.T) {
+	service := &callService{}
+	server := ethrpc.NewServer()
+	require.Nil(t, server.RegisterName("eth", service))
+	defer server.Stop()
+	httpServer := httptest.NewServer(server)
+	defer httpServer.Close()
+
+	cfg, err := config.UnmarshalConfig(t.TempDir(), "./testdata/config/bitxhub.toml")
+	require.Nil(t, err)
+	cfg.Transport = config.Transport{}
+	cli, err := NewFromConfig(cfg, WithUrls([]string{httpServer.URL}))
+	require.Nil(t, err)
+	defer cli.Stop()
+	from := common.HexToAddress("0x450c8a57bae0aa50fa5122c84419d2b2924f205d")
+	require.Equal(t, from, cli.DefaultSigner().Address())
+
+	// EthCall is sent from the default account
+	contractAbi, err := utils.LoadAbi("./testdata/storage.abi")
+	require.Nil(t, err)
+	res, err := cli.EthCall(&contractAbi, "0x47bd692d7728dee508a2791701d54597cc1b8100", "retrieve", nil)
+	require.Nil(t, err)
+	require.Equal(t, "5", res[0].(*big.Int).String())
+	require.Equal(t, from, service.from.Load())
+
+	cfg.Account.PasswordFile = ""
+	cfg.Account.Password = "wrong"
+	_, err = NewFromConfig(cfg, WithUrls([]string{httpServer.URL}))
+	require.NotNil(t, err)
+}
@@ -0,0 +1,143 @@
+package go_eth_client
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"sync/atomic"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind/backends"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+var _ Client = (*Simulated)(nil)
+
+const (
+	simulatedUrl      = "simulated"
+	simulatedGasLimit = 1000000000 // 模拟链的区块gas上限
+)
+
+// Simulated is a Client backed by an in-memory simulated chain, it runs the same
+// code paths as EthRPC so contract interactions can be tested in-process.
+type Simulated struct {
+	*EthRPC
+	backend  *backends.SimulatedBackend
+	automine int32
+}
+
+// NewSimulated creates a simulated chain with the given genesis allocation.
+// Every transaction is mined into its own block until SetAutomine(false) is called,
+// after that pending transactions are only included by Commit.
+func NewSimulated(alloc core.GenesisAlloc, opts ...Option) (*Simulated, error) {
+	sim := &Simulated{
+		backend:  backends.NewSimulatedBackend(alloc, simulatedGasLimit),
+		automine: 1,
+	}
+	conn := &simulatedConn{SimulatedBackend: sim.backend, sim: sim}
+	opts = append(opts, WithUrls([]string{simulatedUrl}), withFactory(func() (Conn, string, error) {
+		return conn, simulatedUrl, nil
+	}))
+	rpc, err := New(opts...)
+	if err != nil {
+		_ = sim.backend.Close()
+		return nil, err
+	}
+	sim.EthRPC = rpc
+	return sim, nil
+}
+
+func withFactory(factory Factory) Option {
+	return func(config *EthRPC) {
+		config.factory = factory
+	}
+}
+
+// SetAutomine switches between mining a block for every transaction and explicit Commit
+func (sim *Simulated) SetAutomine(automine bool) {
+	if automine {
+		atomic.StoreInt32(&sim.automine, 1)
+		return
+	}
+	atomic.StoreInt32(&sim.automine, 0)
+}
+
+// Commit mines all pending transactions into a new block
+func (sim *Simulated) Commit() {
+	sim.backend.Commit()
+}
+
+// Rollback drops all pending transactions
+func (sim *Simulated) Rollback() {
+	sim.backend.Rollback()
+}
+
+// AdjustTime moves the timestamp of the pending block forward
+func (sim *Simulated) AdjustTime(adjustment time.Duration) error {
+	return sim.backend.AdjustTime(adjustment)
+}
+
+// Backend returns the underlying simulated backend
+func (sim *Simulated) Backend() *backends.SimulatedBackend {
+	return sim.backend
+}
+
+func (sim *Simulated) Stop() {
+	sim.EthRPC.Stop()
+	_ = sim.backend.Close()
+}
+
+// simulatedConn adapts the simulated backend to the behaviour of a real node
+type simulatedConn struct {
+	*backends.SimulatedBackend
+	sim *Simulated
+}
+
+func (c *simulatedConn) ChainID(ctx context.Context) (*big.Int, error) {
+	return c.Blockchain().Config().ChainID, nil
+}
+
+// Close is a no-op, the backend is shared by all connections of the pool
+func (c *simulatedConn) Close() {}
+
+func (c *simulatedConn) TransactionReceipt(ctx context.Context, txHash common.Hash) (*types.Receipt, error) {
+	receipt, err := c.SimulatedBackend.TransactionReceipt(ctx, txHash)
+	if err != nil {
+		return nil, err
+	}
+	if receipt == nil {
+		return nil, ethereum.NotFound
+	}
+	return receipt, nil
+}
+
+// SuggestGasPrice returns a price covering the base fee of the next block
+func (c *simulatedConn) SuggestGasPrice(ctx context.Context) (*big.Int, error) {
+	header, err := c.HeaderByNumber(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	if header.BaseFee == nil {
+		return big.NewInt(1), nil
+	}
+	return new(big.Int).Mul(header.BaseFee, big.NewInt(2)), nil
+}
+
+// SendTransaction reports invalid transactions as errors instead of panicking
+func (c *simulatedConn) SendTransaction(ctx context.Context, tx *types.Transaction) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("send transaction: %v", r)
+		}
+	}()
+	if err := c.SimulatedBackend.SendTransaction(ctx, tx); err != nil {
+		return err
+	}
+	if atomic.LoadInt32(&c.sim.automine) == 1 {
+		c.SimulatedBackend.Commit()
+	}
+	return nil
+}
@@ -0,0 +1,369 @@
+package go_eth_client
+
+import (
+	"io/ioutil"
+	"math/big"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/meshplus/go-eth-client/compiler"
+	"github.com/meshplus/go-eth-client/internal/compilertest"
+	"github.com/meshplus/go-eth-client/utils"
+	"github.com/stretchr/testify/require"
+)
+
+func newSimulated(t *testing.T) (*Simulated, *big.Int) {
+	pk, err := crypto.GenerateKey()
+	require.Nil(t, err)
+	balance := new(big.Int).Exp(big.NewInt(10), big.NewInt(30), nil)
+	sim, err := NewSimulated(core.GenesisAlloc{
+		crypto.PubkeyToAddress(pk.PublicKey): {Balance: balance},
+	}, WithPriKey(pk))
+	require.Nil(t, err)
+	return sim, balance
+}
+
+func TestSimulatedDeployAndInvoke(t *testing.T) {
+	sim, balance := newSimulated(t)
+	defer sim.Stop()
+	pk := sim.privateKey
+
+	got, err := sim.EthGetBalance(crypto.PubkeyToAddress(pk.PublicKey), nil)
+	require.Nil(t, err)
+	require.Equal(t, balance, got)
+
+	contractAbi, err := utils.LoadAbi("./testdata/storage.abi")
+	require.Nil(t, err)
+	code, err := ioutil.ReadFile("./testdata/storage.bin")
+	require.Nil(t, err)
+	address, blockNum, err := sim.DeployByCode(pk, contractAbi, string(code), nil)
+	require.Nil(t, err)
+	block, err := sim.EthGetBlockByNumber(nil, true)
+	require.Nil(t, err)
+	require.Equal(t, blockNum, block.NumberU64())
+
+	args, err := utils.Decode(&contractAbi, "store", "5")
+	require.Nil(t, err)
+	res, err := sim.InvokeWithReceipt(pk, &contractAbi, address, "store", args)
+	require.Nil(t, err)
+	receipt, ok := res[0].(*types.Receipt)
+	require.True(t, ok)
+	require.Equal(t, types.ReceiptStatusSuccessful, receipt.Status)
+
+	callRes, err := sim.EthCall(&contractAbi, address, "retrieve", nil)
+	require.Nil(t, err)
+	require.Equal(t, "5", callRes[0].(*big.Int).String())
+}
+
+func TestSimulatedCommit(t *testing.T) {
+	sim, _ := newSimulated(t)
+	defer sim.Stop()
+	sim.SetAutomine(false)
+	pk := sim.privateKey
+
+	to := crypto.PubkeyToAddress(pk.PublicKey)
+	price, err := sim.EthGasPrice()
+	require.Nil(t, err)
+	nonce, err := sim.EthGetTransactionCount(to, nil)
+	require.Nil(t, err)
+	var hashes []common.Hash
+	for i := uint64(0); i < 2; i++ {
+		hash, err := sim.EthSendTransaction(pk, utils.NewTransaction(nonce+i, to, 21000, price, nil, big.NewInt(1)))
+		require.Nil(t, err)
+		hashes = append(hashes, hash)
+	}
+	block, err := sim.EthGetBlockByNumber(nil, false)
+	require.Nil(t, err)
+	require.Equal(t, uint64(0), block.NumberU64())
+	// the nonce of the latest block, like a real node, doesn't count the pending transactions
+	latest, err := sim.EthGetTransactionCount(to, nil)
+	require.Nil(t, err)
+	require.Equal(t, nonce, latest)
+
+	sim.Commit()
+	latest, err = sim.EthGetTransactionCount(to, nil)
+	require.Nil(t, err)
+	require.Equal(t, nonce+2, latest)
+	block, err = sim.EthGetBlockByNumber(nil, true)
+	require.Nil(t, err)
+	require.Equal(t, uint64(1), block.NumberU64())
+	require.Equal(t, 2, block.Transactions().Len())
+	for _, hash := range hashes {
+		receipt, err := sim.EthGetTransactionReceipt(hash)
+		require.Nil(t, err)
+		require.Equal(t, types.ReceiptStatusSuccessful, receipt.Status)
+	}
+}
+
+func TestSimulatedCompileAndDeploy(t *testing.T) {
+	solc := compilertest.NewSolc(t, "")
+	cache, err := compiler.NewCache(t.TempDir())
+	require.Nil(t, err)
+	pk, err := crypto.GenerateKey()
+	require.Nil(t, err)
+	sim, err := NewSimulated(core.GenesisAlloc{
+		crypto.PubkeyToAddress(pk.PublicKey): {Balance: new(big.Int).Exp(big.NewInt(10), big.NewInt(30), nil)},
+	}, WithPriKey(pk), WithCompiler(compiler.New(compiler.WithSolc(solc.Path), compiler.WithOptimizer(200), compiler.WithCache(cache))))
+	require.Nil(t, err)
+	defer sim.Stop()
+
+	result, err := sim.Compile("./testdata/storage.sol")
+	require.Nil(t, err)
+	require.Equal(t, []string{"testdata/storage.sol:Storage"}, result.Names)
+	cached, err := sim.Compile("./testdata/storage.sol")
+	require.Nil(t, err)
+	require.Equal(t, result, cached)
+	require.Equal(t, 1, len(solc.Calls(t)))
+	addresses, err := sim.DeployWithReceipt(nil, result, nil)
+	require.Nil(t, err)
+	require.Equal(t, 1, len(addresses))
+
+	contractAbi, err := utils.LoadAbi("./testdata/storage.abi")
+	require.Nil(t, err)
+	args, err := utils.Decode(&contractAbi, "store", "8")
+	require.Nil(t, err)
+	_, err = sim.InvokeWithReceipt(nil, &contractAbi, addresses[0], "store", args)
+	require.Nil(t, err)
+	callRes, err := sim.EthCall(&contractAbi, addresses[0], "retrieve", nil)
+	require.Nil(t, err)
+	require.Equal(t, "8", callRes[0].(*big.Int).String())
+}
+
+func TestSimulatedCompileSources(t *testing.T) {
+	solc := compilertest.NewSolc(t, "")
+	sim, _ := newSimulated(t)
+	defer sim.Stop()
+	sim.compiler = compiler.New(compiler.WithSolc(solc.Path))
+
+	result, err := sim.CompileSources(map[string]string{
+		"gen/Store.sol": "import \"storage.sol\";\ncontract Store is Storage {}\n",
+	}, compiler.FSImporter(os.DirFS("./testdata")))
+	require.Nil(t, err)
+	require.Equal(t, []string{"gen/Store.sol:Store", "storage.sol:Storage"}, result.Names)
+	contractAbi, err := abi.JSON(strings.NewReader(result.Abi[0]))
+	require.Nil(t, err)
+	_, _, err = sim.DeployByCode(nil, contractAbi, result.Bin[0], nil)
+	require.Nil(t, err)
+}
+
+func TestSimulatedDeployContracts(t *testing.T) {
+	solc := compilertest.NewSolc(t, "")
+	sim, _ := newSimulated(t)
+	defer sim.Stop()
+	sim.compiler = compiler.New(compiler.WithSolc(solc.Path))
+
+	result, err := sim.CompileSources(map[string]string{
+		"gen/Store.sol": `import "storage.sol";
+abstract contract Base {}
+interface IStore {}
+contract Store is Base, IStore, Storage {}
+`,
+	}, compiler.FSImporter(os.DirFS("./testdata")))
+	require.Nil(t, err)
+	require.Equal(t, []string{"gen/Store.sol:Base", "gen/Store.sol:IStore", "gen/Store.sol:Store", "storage.sol:Storage"}, result.Names)
+	require.True(t, result.Artifacts["gen/Store.sol:Base"].Abstract)
+	require.Equal(t, compiler.KindInterface, result.Artifacts["gen/Store.sol:IStore"].Kind)
+
+	addresses, err := sim.DeployContracts(nil, result, map[string][]interface{}{
+		"Store":               nil,
+		"storage.sol:Storage": {},
+	})
+	require.Nil(t, err)
+	require.Equal(t, 2, len(addresses))
+	require.NotEqual(t, addresses["gen/Store.sol:Store"], addresses["storage.sol:Storage"])
+	for _, address := range addresses {
+		code, err := sim.EthGetCode(common.HexToAddress(address), nil)
+		require.Nil(t, err)
+		require.NotEmpty(t, code)
+	}
+
+	_, err = sim.DeployContracts(nil, result, map[string][]interface{}{"Base": nil})
+	require.NotNil(t, err)
+	require.Contains(t, err.Error(), "abstract")
+	_, err = sim.DeployContracts(nil, result, map[string][]interface{}{"IStore": nil})
+	require.NotNil(t, err)
+	_, err = sim.DeployContracts(nil, result, map[string][]interface{}{"Missing": nil})
+	require.NotNil(t, err)
+	// Storage has no constructor arguments
+	_, err = sim.DeployContracts(nil, result, map[string][]interface{}{"Storage": {big.NewInt(1)}})
+	require.NotNil(t, err)
+	require.Contains(t, err.Error(), "storage.sol:Storage")
+}
+
+func TestSimulatedDeployAll(t *testing.T) {
+	solc := compilertest.NewSolc(t, "")
+	sim, _ := newSimulated(t)
+	defer sim.Stop()
+	sim.compiler = compiler.New(compiler.WithSolc(solc.Path))
+
+	result, err := sim.CompileSources(map[string]string{
+		"gen/Store.sol": "import \"storage.sol\";\nabstract contract Base {}\ncontract Store is Base, Storage {}\n",
+	}, compiler.FSImporter(os.DirFS("./testdata")))
+	require.Nil(t, err)
+	nonce, err := sim.EthGetTransactionCount(crypto.PubkeyToAddress(sim.privateKey.PublicKey), nil)
+	require.Nil(t, err)
+
+	results, err := sim.DeployAll(nil, result, nil)
+	require.Nil(t, err)
+	require.Equal(t, 2, len(results))
+	for i, res := range results {
+		require.Nil(t, res.Err)
+		require.Equal(t, nonce+uint64(i), res.Nonce)
+		require.Equal(t, crypto.PubkeyToAddress(sim.privateKey.PublicKey), res.From)
+		require.Equal(t, types.ReceiptStatusSuccessful, res.Status)
+		require.NotZero(t, res.GasUsed)
+		require.NotZero(t, res.BlockNumber)
+		code, err := sim.EthGetCode(res.Address, nil)
+		require.Nil(t, err)
+		require.NotEqual(t, "0x", code)
+	}
+	require.Equal(t, "gen/Store.sol:Store", results[0].Name)
+	require.Equal(t, "storage.sol:Storage", results[1].Name)
+	require.NotEqual(t, results[0].Address, results[1].Address)
+
+	// the transactions are pending together before they are mined
+	sim.SetAutomine(false)
+	addresses, err := sim.Deploy(nil, result, nil)
+	require.Nil(t, err)
+	require.Equal(t, 2, len(addresses))
+	sim.Commit()
+	for _, address := range addresses {
+		code, err := sim.EthGetCode(common.HexToAddress(address), nil)
+		require.Nil(t, err)
+		require.NotEqual(t, "0x", code)
+	}
+}
+
+func TestSimulatedDeployAllPartialFailure(t *testing.T) {
+	sim, _ := newSimulated(t)
+	defer sim.Stop()
+
+	code, err := ioutil.ReadFile("./testdata/storage.bin")
+	require.Nil(t, err)
+	storageAbi, err := ioutil.ReadFile("./testdata/storage.abi")
+	require.Nil(t, err)
+	result := &CompileResult{
+		Abi:   []string{string(storageAbi), "[]", "not json", string(storageAbi)},
+		Bin:   []string{string(code), "0xfe", string(code), string(code)},
+		Names: []string{"A", "Invalid", "BadAbi", "B"},
+	}
+	results, err := sim.DeployAll(nil, result, nil)
+	require.NotNil(t, err)
+	deployErr, ok := err.(*DeploymentError)
+	require.True(t, ok)
+	require.Equal(t, 4, len(results))
+	failed := deployErr.Failed()
+	require.Equal(t, 2, len(failed))
+	require.Equal(t, "Invalid", failed[0].Name)
+	require.Equal(t, "BadAbi", failed[1].Name)
+	require.Contains(t, err.Error(), "deploy 2 of 4 contracts failed")
+
+	// the contract after the ones failed still gets the next nonce
+	require.Equal(t, results[0].Nonce+2, results[3].Nonce)
+	for _, res := range []*DeploymentResult{results[0], results[3]} {
+		require.Nil(t, res.Err)
+		code, err := sim.EthGetCode(res.Address, nil)
+		require.Nil(t, err)
+		require.NotEqual(t, "0x", code)
+	}
+
+	_, err = sim.DeployWithReceipt(nil, result, nil)
+	require.NotNil(t, err)
+}
+
+// storedAbi is the abi of a contract logging Stored(msg.sender, value) for every call
+const storedAbi = `[{"inputs":[{"internalType":"uint256","name":"value","type":"uint256"}],"name":"store","outputs":[],"stateMutability":"nonpayable","type":"function"},
+{"anonymous":false,"inputs":[{"indexed":true,"internalType":"address","name":"who","type":"address"},{"indexed":false,"internalType":"uint256","name":"value","type":"uint256"}],"name":"Stored","type":"event"}]`
+
+// storedCode returns the creation code of the contract of storedAbi
+func storedCode() string {
+	topic := crypto.Keccak256Hash([]byte("Stored(address,uint256)"))
+	// calldataload(4), mstore(0), caller, push32 topic, log2(0, 32, topic, caller), stop
+	runtime := "600435600052337f" + common.Bytes2Hex(topic.Bytes()) + "60206000a200"
+	return "0x602e80600b6000396000f3" + runtime
+}
+
+func TestSimulatedResults(t *testing.T) {
+	sim, _ := newSimulated(t)
+	defer sim.Stop()
+	from := crypto.PubkeyToAddress(sim.privateKey.PublicKey)
+
+	contractAbi, err := abi.JSON(strings.NewReader(storedAbi))
+	require.Nil(t, err)
+	deployment, err := sim.DeployByCodeWithResult(nil, contractAbi, storedCode(), nil)
+	require.Nil(t, err)
+	require.True(t, deployment.Mined())
+	require.Equal(t, from, deployment.From)
+	require.Equal(t, uint64(0), deployment.Nonce)
+	require.Equal(t, types.ReceiptStatusSuccessful, deployment.Status)
+	require.Equal(t, crypto.CreateAddress(from, 0), deployment.Address)
+	require.NotZero(t, deployment.GasUsed)
+	block, err := sim.EthGetBlockByNumber(new(big.Int).SetUint64(deployment.BlockNumber), false)
+	require.Nil(t, err)
+	require.Equal(t, block.Hash(), deployment.BlockHash)
+	require.Equal(t, 1, deployment.EffectiveGasPrice.Sign())
+	require.Empty(t, deployment.Events)
+
+	price := big.NewInt(2000000000)
+	invocation, err := sim.InvokeWithResult(nil, &contractAbi, deployment.Address.Hex(), "store", []interface{}{big.NewInt(7)},
+		WithGasPrice(price))
+	require.Nil(t, err)
+	require.Equal(t, "store", invocation.Method)
+	require.Equal(t, deployment.Address, invocation.To)
+	require.Equal(t, from, invocation.From)
+	require.Equal(t, uint64(1), invocation.Nonce)
+	require.Equal(t, types.ReceiptStatusSuccessful, invocation.Status)
+	require.Equal(t, price, invocation.EffectiveGasPrice)
+	require.Equal(t, invocation.TxHash, invocation.Receipt.TxHash)
+	require.Equal(t, 1, len(invocation.Events))
+	event := invocation.Events[0]
+	require.Equal(t, "Stored", event.Name)
+	require.Equal(t, deployment.Address, event.Address)
+	require.Equal(t, from, event.Args["who"])
+	require.Equal(t, big.NewInt(7), event.Args["value"])
+
+	// the logs of other contracts are kept undecoded
+	storageAbi, err := utils.LoadAbi("./testdata/storage.abi")
+	require.Nil(t, err)
+	events := DecodeEvents(&storageAbi, invocation.Receipt.Logs)
+	require.Equal(t, 1, len(events))
+	require.Equal(t, "", events[0].Name)
+	require.Equal(t, invocation.Receipt.Logs[0], events[0].Log)
+
+	// a read-only method is called without a transaction
+	code, err := ioutil.ReadFile("./testdata/storage.bin")
+	require.Nil(t, err)
+	storage, err := sim.DeployByCodeWithResult(nil, storageAbi, string(code), nil)
+	require.Nil(t, err)
+	read, err := sim.InvokeWithResult(nil, &storageAbi, storage.Address.Hex(), "retrieve", nil)
+	require.Nil(t, err)
+	require.False(t, read.Mined())
+	require.Equal(t, common.Hash{}, read.TxHash)
+	require.Equal(t, "0", read.Outputs[0].(*big.Int).String())
+
+	// the legacy methods keep their results
+	res, err := sim.InvokeWithReceipt(nil, &contractAbi, deployment.Address.Hex(), "store", []interface{}{big.NewInt(8)})
+	require.Nil(t, err)
+	_, ok := res[0].(*types.Receipt)
+	require.True(t, ok)
+	res, err = sim.Invoke(nil, &contractAbi, deployment.Address.Hex(), "store", []interface{}{big.NewInt(9)})
+	require.Nil(t, err)
+	_, ok = res[0].(common.Hash)
+	require.True(t, ok)
+
+	failed, err := sim.DeployByCodeWithResult(nil, abi.ABI{}, "0xfe", nil)
+	require.NotNil(t, err)
+	require.Equal(t, types.ReceiptStatusFailed, failed.Status)
+	require.Equal(t, err, failed.Err)
+}
+
+// TestSolcStandIn runs the solc stand-in of compilertest
+func TestSolcStandIn(t *testing.T) {
+	compilertest.Main()
+}
@@ -0,0 +1,216 @@
+package go_eth_client
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/ethereum/go-ethereum/ethclient"
+	ethrpc "github.com/ethereum/go-ethereum/rpc"
+	"github.com/gorilla/websocket"
+)
+
+// HeaderFunc returns the extra headers attached to a request sent to the given url.
+// It is called for every request, so it can hand out short-lived credentials such
+// as rotating JWTs.
+type HeaderFunc func(url string) (http.Header, error)
+
+func WithHTTPClient(client *http.Client) Option {
+	return func(config *EthRPC) {
+		config.httpClient = client
+	}
+}
+
+// WithHeader adds a header to the requests sent to url, an empty url means all urls.
+func WithHeader(url, key, value string) Option {
+	return func(config *EthRPC) {
+		if config.headers == nil {
+			config.headers = make(map[string]http.Header)
+		}
+		if config.headers[url] == nil {
+			config.headers[url] = make(http.Header)
+		}
+		config.headers[url].Add(key, value)
+	}
+}
+
+func WithHeaderFunc(f HeaderFunc) Option {
+	return func(config *EthRPC) {
+		config.headerFunc = f
+	}
+}
+
+func WithTLSConfig(tlsConfig *tls.Config) Option {
+	return func(config *EthRPC) {
+		config.tlsConfig = tlsConfig
+	}
+}
+
+func WithProxy(proxy func(*http.Request) (*url.URL, error)) Option {
+	return func(config *EthRPC) {
+		config.proxy = proxy
+	}
+}
+
+func WithHTTPTimeout(t time.Duration) Option {
+	return func(config *EthRPC) {
+		config.httpTimeout = t
+	}
+}
+
+// checkTransport returns an error if the transport settings can't be applied to every url
+func (rpc *EthRPC) checkTransport() error {
+	for _, rawurl := range rpc.urls {
+		u, err := url.Parse(rawurl)
+		if err != nil {
+			return fmt.Errorf("parse url %s: %w", rawurl, err)
+		}
+		switch u.Scheme {
+		case "http", "https":
+			if _, err := rpc.httpClientFor(rawurl); err != nil {
+				return err
+			}
+		case "ws", "wss":
+			if err := rpc.checkWebsocketHeaders(rawurl); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// checkWebsocketHeaders returns an error if headers are configured for the websocket url rawurl,
+// the websocket client of go-ethereum only sends the origin and the basic auth of the url
+// in the handshake, so they would be dropped silently
+func (rpc *EthRPC) checkWebsocketHeaders(rawurl string) error {
+	if len(rpc.headers[""]) != 0 || len(rpc.headers[rawurl]) != 0 || rpc.headerFunc != nil {
+		return fmt.Errorf("headers can't be sent to websocket url %s, use http or the user info of the url", rawurl)
+	}
+	return nil
+}
+
+// dial connects to rawurl with the configured transport settings.
+// Headers are only sent over http, like ethrpc.Client.SetHeader.
+func (rpc *EthRPC) dial(ctx context.Context, rawurl string) (*ethclient.Client, error) {
+	u, err := url.Parse(rawurl)
+	if err != nil {
+		return nil, err
+	}
+
+	var client *ethrpc.Client
+	switch u.Scheme {
+	case "http", "https":
+		var httpClient *http.Client
+		if httpClient, err = rpc.httpClientFor(rawurl); err != nil {
+			return nil, err
+		}
+		client, err = ethrpc.DialHTTPWithClient(rawurl, httpClient)
+	case "ws", "wss":
+		if err := rpc.checkWebsocketHeaders(rawurl); err != nil {
+			return nil, err
+		}
+		dialer := websocket.Dialer{
+			Proxy:            rpc.proxy,
+			TLSClientConfig:  rpc.tlsConfig,
+			HandshakeTimeout: rpc.httpTimeout,
+		}
+		client, err = ethrpc.DialWebsocketWithDialer(ctx, rawurl, "", dialer)
+	case "unix", "ipc":
+		client, err = ethrpc.DialIPC(ctx, ipcPath(u))
+	default:
+		client, err = ethrpc.DialContext(ctx, rawurl)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return ethclient.NewClient(client), nil
+}
+
+// ipcPath returns the socket path of urls like unix:///tmp/geth.ipc, ipc:///tmp/geth.ipc or ipc:geth.ipc
+func ipcPath(u *url.URL) string {
+	if u.Opaque != "" {
+		return u.Opaque
+	}
+	return u.Host + u.Path
+}
+
+// httpClientFor returns the http client used for requests to rawurl. The tls, proxy and timeout
+// settings are applied to a copy of the client set by WithHTTPClient, whose transport has to be
+// an *http.Transport if any of the tls and proxy settings is given.
+func (rpc *EthRPC) httpClientFor(rawurl string) (*http.Client, error) {
+	client := &http.Client{}
+	if rpc.httpClient != nil {
+		*client = *rpc.httpClient
+	}
+	if rpc.tlsConfig != nil || rpc.proxy != nil || client.Transport == nil {
+		base := client.Transport
+		if base == nil {
+			base = http.DefaultTransport
+		}
+		transport, ok := base.(*http.Transport)
+		if !ok {
+			return nil, fmt.Errorf("tls and proxy settings can't be applied to the http client transport %T", base)
+		}
+		transport = transport.Clone()
+		if rpc.tlsConfig != nil {
+			transport.TLSClientConfig = rpc.tlsConfig
+		}
+		if rpc.proxy != nil {
+			transport.Proxy = rpc.proxy
+		}
+		client.Transport = transport
+	}
+	if rpc.httpTimeout > 0 {
+		client.Timeout = rpc.httpTimeout
+	}
+
+	headers := make(http.Header)
+	for _, u := range []string{"", rawurl} {
+		for key, values := range rpc.headers[u] {
+			headers[key] = values
+		}
+	}
+	if len(headers) == 0 && rpc.headerFunc == nil {
+		return client, nil
+	}
+
+	base := client.Transport
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	client.Transport = &headerTransport{
+		base:       base,
+		url:        rawurl,
+		headers:    headers,
+		headerFunc: rpc.headerFunc,
+	}
+	return client, nil
+}
+
+// headerTransport is a http.RoundTripper setting extra headers on every request
+type headerTransport struct {
+	base       http.RoundTripper
+	url        string
+	headers    http.Header
+	headerFunc HeaderFunc
+}
+
+func (t *headerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	for key, values := range t.headers {
+		req.Header[key] = values
+	}
+	if t.headerFunc != nil {
+		headers, err := t.headerFunc(t.url)
+		if err != nil {
+			return nil, fmt.Errorf("get headers for %s: %w", t.url, err)
+		}
+		for key, values := range headers {
+			req.Header[key] = values
+		}
+	}
+	return t.base.RoundTrip(req)
+}
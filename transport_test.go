@@ -0,0 +1,112 @@
+package go_eth_client
+
+import (
+	"crypto/tls"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	ethrpc "github.com/ethereum/go-ethereum/rpc"
+	"github.com/stretchr/testify/require"
+)
+
+type chainIdService struct{}
+
+func (s *chainIdService) ChainId() hexutil.Uint64 {
+	return 1356
+}
+
+func TestTransportHeaders(t *testing.T) {
+	server := ethrpc.NewServer()
+	require.Nil(t, server.RegisterName("eth", &chainIdService{}))
+	defer server.Stop()
+
+	var tokenCount int32
+	var authorization, apiKey atomic.Value
+	httpServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authorization.Store(r.Header.Get("Authorization"))
+		apiKey.Store(r.Header.Get("X-Api-Key"))
+		server.ServeHTTP(w, r)
+	}))
+	defer httpServer.Close()
+
+	cli, err := New(
+		WithUrls([]string{httpServer.URL}),
+		WithPoolInit(1),
+		WithPoolSize(1),
+		WithHeader("", "X-Api-Key", "bitxhub"),
+		WithHeaderFunc(func(url string) (http.Header, error) {
+			header := make(http.Header)
+			header.Set("Authorization", "Bearer "+hexutil.EncodeUint64(uint64(atomic.AddInt32(&tokenCount, 1))))
+			return header, nil
+		}),
+	)
+	require.Nil(t, err)
+	defer cli.Stop()
+	require.Equal(t, uint64(1356), cli.EthGetChainId().Uint64())
+	require.Equal(t, "Bearer 0x1", authorization.Load())
+	require.Equal(t, "bitxhub", apiKey.Load())
+}
+
+func TestTransportWithHTTPClient(t *testing.T) {
+	server := ethrpc.NewServer()
+	require.Nil(t, server.RegisterName("eth", &chainIdService{}))
+	defer server.Stop()
+	httpServer := httptest.NewTLSServer(server)
+	defer httpServer.Close()
+
+	// the tls config is applied to the transport of the given client
+	cli, err := New(
+		WithUrls([]string{httpServer.URL}),
+		WithPoolInit(1),
+		WithPoolSize(1),
+		WithHTTPClient(&http.Client{}),
+		WithTLSConfig(&tls.Config{InsecureSkipVerify: true}),
+	)
+	require.Nil(t, err)
+	require.Equal(t, uint64(1356), cli.EthGetChainId().Uint64())
+	cli.Stop()
+
+	_, err = New(
+		WithUrls([]string{httpServer.URL}),
+		WithHTTPClient(&http.Client{Transport: &headerTransport{base: http.DefaultTransport}}),
+		WithTLSConfig(&tls.Config{InsecureSkipVerify: true}),
+	)
+	require.NotNil(t, err)
+}
+
+func TestWebsocketHeaders(t *testing.T) {
+	_, err := New(WithUrls([]string{"ws://localhost:8545"}), WithHeader("", "Authorization", "Bearer token"))
+	require.NotNil(t, err)
+	require.Contains(t, err.Error(), "websocket")
+	_, err = New(WithUrls([]string{"ws://localhost:8545"}), WithHeader("ws://localhost:8545", "X-Api-Key", "key"))
+	require.NotNil(t, err)
+}
+
+func TestIPCTransport(t *testing.T) {
+	server := ethrpc.NewServer()
+	require.Nil(t, server.RegisterName("eth", &chainIdService{}))
+	defer server.Stop()
+
+	dir, err := ioutil.TempDir("", "ipc")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+	endpoint := filepath.Join(dir, "bitxhub.ipc")
+	listener, err := net.Listen("unix", endpoint)
+	require.Nil(t, err)
+	defer listener.Close()
+	go server.ServeListener(listener)
+
+	for _, url := range []string{"unix://" + endpoint, "ipc:" + endpoint} {
+		cli, err := New(WithUrls([]string{url}), WithPoolInit(1), WithPoolSize(1))
+		require.Nil(t, err)
+		require.Equal(t, uint64(1356), cli.EthGetChainId().Uint64())
+		cli.Stop()
+	}
+}
@@ -2,13 +2,88 @@ package go_eth_client
 
 import (
 	"crypto/ecdsa"
+	"fmt"
 	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/meshplus/go-eth-client/signer"
 )
 
 type CompileResult struct {
-	Abi   []string
-	Bin   []string
-	Names []string
+	Abi       []string
+	Bin       []string
+	Names     []string
+	Artifacts Artifacts // 按合约全名(file.sol:Contract)索引的编译产物
+}
+
+// TransactionResult is the outcome of a transaction, the fields from the receipt are only set once it is mined
+type TransactionResult struct {
+	TxHash            common.Hash    // 交易哈希
+	From              common.Address // 交易发送者
+	Nonce             uint64         // 交易的nonce
+	GasUsed           uint64         // 消耗的gas
+	EffectiveGasPrice *big.Int       // 实际支付的gas价格
+	BlockNumber       uint64         // 交易所在区块号
+	BlockHash         common.Hash    // 交易所在区块哈希
+	Status            uint64         // 交易状态，1为成功，0为失败
+	Events            []*Event       // 按合约ABI解析的事件
+	Receipt           *types.Receipt // 交易回执
+}
+
+// Mined reports whether the receipt of the transaction is got
+func (r *TransactionResult) Mined() bool {
+	return r.Receipt != nil
+}
+
+// Event is a log of a transaction decoded by the abi of the contract
+type Event struct {
+	Name    string                 // 事件名，无法解析的日志为空
+	Address common.Address         // 产生事件的合约地址
+	Args    map[string]interface{} // 事件参数名 -> 参数值，包括indexed参数
+	Log     *types.Log             // 原始日志
+}
+
+// DeploymentResult is the result of deploying a contract
+type DeploymentResult struct {
+	TransactionResult
+	Name    string         // 合约全名
+	Address common.Address // 合约地址
+	Err     error          // 部署失败的原因
+}
+
+// InvocationResult is the result of invoking a contract method, read-only methods are called without a transaction
+// and only have Outputs
+type InvocationResult struct {
+	TransactionResult
+	Method  string         // 调用的方法名
+	To      common.Address // 合约地址
+	Outputs []interface{}  // 只读方法的返回值
+}
+
+// DeploymentError reports the contracts failed to deploy, Results hold the results of all the contracts
+type DeploymentError struct {
+	Results []*DeploymentResult
+}
+
+func (e *DeploymentError) Error() string {
+	var failed []string
+	for _, res := range e.Failed() {
+		failed = append(failed, fmt.Sprintf("%s: %s", res.Name, res.Err))
+	}
+	return fmt.Sprintf("deploy %d of %d contracts failed: %s", len(failed), len(e.Results), strings.Join(failed, "; "))
+}
+
+// Failed returns the results of the contracts failed to deploy
+func (e *DeploymentError) Failed() []*DeploymentResult {
+	var failed []*DeploymentResult
+	for _, res := range e.Results {
+		if res.Err != nil {
+			failed = append(failed, res)
+		}
+	}
+	return failed
 }
 
 type TransactionOptions struct {
@@ -16,6 +91,7 @@ type TransactionOptions struct {
 	GasPrice   *big.Int
 	Nonce      uint64
 	PrivateKey *ecdsa.PrivateKey
+	Signer     signer.Signer // signs the transaction instead of the private key
 }
 
 type TransactionOption func(opts *TransactionOptions)
@@ -26,6 +102,19 @@ func WithNonce(nonce uint64) TransactionOption {
 	}
 }
 
+// nextNonce returns opts for the transaction sent after the one of nonce. An explicit nonce is advanced,
+// so that transactions sent one by one with WithNonce get consecutive nonces.
+func nextNonce(opts []TransactionOption, nonce uint64) []TransactionOption {
+	transactionOpts := &TransactionOptions{}
+	for _, opt := range opts {
+		opt(transactionOpts)
+	}
+	if transactionOpts.Nonce == 0 {
+		return opts
+	}
+	return append(opts[:len(opts):len(opts)], WithNonce(nonce+1))
+}
+
 func WithGasPrice(price *big.Int) TransactionOption {
 	return func(opts *TransactionOptions) {
 		opts.GasPrice = price
@@ -37,3 +126,10 @@ func WithGasLimit(limit uint64) TransactionOption {
 		opts.GasLimit = limit
 	}
 }
+
+// WithSigner signs the transaction with s, the private key passed to the method may be nil then
+func WithSigner(s signer.Signer) TransactionOption {
+	return func(opts *TransactionOptions) {
+		opts.Signer = s
+	}
+}
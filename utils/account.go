@@ -27,7 +27,12 @@ func GetPrivateKeyAddr(privateKey *ecdsa.PrivateKey) common.Address {
 }
 
 func GenAndStoreAccount(accountPath, password, fileName string) (*ecdsa.PrivateKey, string, error) {
-	account, err := keystore.StoreKey(accountPath, password, keystore.LightScryptN, keystore.LightScryptP)
+	return GenAndStoreAccountWithScrypt(accountPath, password, fileName, LightScrypt)
+}
+
+// GenAndStoreAccountWithScrypt generates a key and stores it encrypted with the scrypt strength params
+func GenAndStoreAccountWithScrypt(accountPath, password, fileName string, params ScryptParams) (*ecdsa.PrivateKey, string, error) {
+	account, err := keystore.StoreKey(accountPath, password, params.N, params.P)
 	if err != nil {
 		return nil, "", err
 	}
@@ -42,13 +47,11 @@ func GenAndStoreAccount(accountPath, password, fileName string) (*ecdsa.PrivateK
 func KeystoreToPrivateKey(privKeyFile, password string) (*ecdsa.PrivateKey, string, error) {
 	keyJson, err := ioutil.ReadFile(privKeyFile)
 	if err != nil {
-		fmt.Println("read keyjson file failed：", err)
+		return nil, "", fmt.Errorf("read keystore: %w", err)
 	}
 	unlockedKey, err := keystore.DecryptKey(keyJson, password)
 	if err != nil {
-
-		return nil, "", err
-
+		return nil, "", fmt.Errorf("decrypt keystore %s: %w", privKeyFile, err)
 	}
 	privKey := unlockedKey.PrivateKey
 	addr := crypto.PubkeyToAddress(unlockedKey.PrivateKey.PublicKey)
@@ -0,0 +1,225 @@
+package utils
+
+import (
+	"fmt"
+	"math/big"
+	"reflect"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// Encode encodes values like Solidity's abi.encode. types are Solidity type names such as
+// "uint64", "bytes[]" or "(uint256,string)[]" for tuples, and values take the string forms
+// accepted by Decode, slices of them for arrays and tuples, or values of the Go types of abi.
+func Encode(types []string, values ...interface{}) ([]byte, error) {
+	args, typed, err := typedValues(types, values)
+	if err != nil {
+		return nil, err
+	}
+	return args.Pack(typed...)
+}
+
+// EncodePacked encodes values like Solidity's abi.encodePacked, with the inputs of Encode.
+// As in Solidity, elements of arrays are padded to 32 bytes and tuples and nested arrays are not supported.
+func EncodePacked(types []string, values ...interface{}) ([]byte, error) {
+	args, typed, err := typedValues(types, values)
+	if err != nil {
+		return nil, err
+	}
+	var packed []byte
+	for i, arg := range args {
+		data, err := encodePacked(arg.Type, reflect.ValueOf(typed[i]))
+		if err != nil {
+			return nil, fmt.Errorf("encode packed %s: %w", types[i], err)
+		}
+		packed = append(packed, data...)
+	}
+	return packed, nil
+}
+
+// Keccak256Encode returns keccak256(abi.encode(values))
+func Keccak256Encode(types []string, values ...interface{}) (common.Hash, error) {
+	data, err := Encode(types, values...)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	return crypto.Keccak256Hash(data), nil
+}
+
+// Keccak256Packed returns keccak256(abi.encodePacked(values))
+func Keccak256Packed(types []string, values ...interface{}) (common.Hash, error) {
+	data, err := EncodePacked(types, values...)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	return crypto.Keccak256Hash(data), nil
+}
+
+// typedValues parses types and converts values to the Go types of abi
+func typedValues(types []string, values []interface{}) (abi.Arguments, []interface{}, error) {
+	if len(types) != len(values) {
+		return nil, nil, fmt.Errorf("the num of values is %d, expected %d", len(values), len(types))
+	}
+	args := make(abi.Arguments, 0, len(types))
+	typed := make([]interface{}, 0, len(values))
+	for i, typ := range types {
+		t, err := parseType(typ)
+		if err != nil {
+			return nil, nil, err
+		}
+		value, err := encodeValue(t, values[i])
+		if err != nil {
+			return nil, nil, fmt.Errorf("convert %v to %s failed: %w", values[i], typ, err)
+		}
+		args = append(args, abi.Argument{Type: t})
+		typed = append(typed, value)
+	}
+	return args, typed, nil
+}
+
+// encodeValue converts value to the Go type of t as convert does, except that a value of the Go type
+// of t is taken as it is and bytes are given in hex with or without 0x prefix
+func encodeValue(t abi.Type, value interface{}) (interface{}, error) {
+	if v := reflect.ValueOf(value); v.IsValid() && v.Kind() != reflect.String &&
+		v.Kind() == t.GetType().Kind() && v.Type().ConvertibleTo(t.GetType()) {
+		return v.Convert(t.GetType()).Interface(), nil
+	}
+	if str, ok := value.(string); ok && t.T == abi.BytesTy {
+		return common.FromHex(str), nil
+	}
+	return convert(t, value)
+}
+
+// parseType parses a Solidity type name, tuples are written as (type1,type2)
+func parseType(typ string) (abi.Type, error) {
+	marshaling, err := parseTypeMarshaling(strings.TrimSpace(typ))
+	if err != nil {
+		return abi.Type{}, err
+	}
+	t, err := abi.NewType(marshaling.Type, "", marshaling.Components)
+	if err != nil {
+		return abi.Type{}, fmt.Errorf("parse type %s: %w", typ, err)
+	}
+	return t, nil
+}
+
+func parseTypeMarshaling(typ string) (abi.ArgumentMarshaling, error) {
+	if !strings.HasPrefix(typ, "(") {
+		return abi.ArgumentMarshaling{Type: typ}, nil
+	}
+	end := closingParen(typ)
+	if end < 0 {
+		return abi.ArgumentMarshaling{}, fmt.Errorf("parse type %s: unbalanced parentheses", typ)
+	}
+	var components []abi.ArgumentMarshaling
+	for i, elem := range splitTopLevel(typ[1:end]) {
+		component, err := parseTypeMarshaling(strings.TrimSpace(elem))
+		if err != nil {
+			return abi.ArgumentMarshaling{}, err
+		}
+		component.Name = fmt.Sprintf("field%d", i)
+		components = append(components, component)
+	}
+	return abi.ArgumentMarshaling{Type: "tuple" + typ[end+1:], Components: components}, nil
+}
+
+// closingParen returns the index of the parenthesis closing the one at the start of s
+func closingParen(s string) int {
+	depth := 0
+	for i, c := range s {
+		switch c {
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// splitTopLevel splits s at the commas which are not inside parentheses
+func splitTopLevel(s string) []string {
+	var (
+		parts []string
+		depth int
+		start int
+	)
+	for i, c := range s {
+		switch c {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		case ',':
+			if depth == 0 {
+				parts = append(parts, s[start:i])
+				start = i + 1
+			}
+		}
+	}
+	return append(parts, s[start:])
+}
+
+func encodePacked(t abi.Type, v reflect.Value) ([]byte, error) {
+	switch t.T {
+	case abi.IntTy, abi.UintTy:
+		n, err := toBigInt(v)
+		if err != nil {
+			return nil, err
+		}
+		return math.U256Bytes(new(big.Int).Set(n))[32-t.Size/8:], nil
+	case abi.BoolTy:
+		if v.Bool() {
+			return []byte{1}, nil
+		}
+		return []byte{0}, nil
+	case abi.AddressTy:
+		address := v.Interface().(common.Address)
+		return address.Bytes(), nil
+	case abi.StringTy:
+		return []byte(v.String()), nil
+	case abi.BytesTy:
+		return v.Bytes(), nil
+	case abi.FixedBytesTy:
+		data := make([]byte, t.Size)
+		reflect.Copy(reflect.ValueOf(data), v)
+		return data, nil
+	case abi.ArrayTy, abi.SliceTy:
+		if t.Elem.T == abi.ArrayTy || t.Elem.T == abi.SliceTy || t.Elem.T == abi.TupleTy ||
+			t.Elem.T == abi.StringTy || t.Elem.T == abi.BytesTy {
+			return nil, fmt.Errorf("%s is not supported in packed mode", t.String())
+		}
+		var packed []byte
+		for i := 0; i < v.Len(); i++ {
+			elem, err := abi.Arguments{{Type: *t.Elem}}.Pack(v.Index(i).Interface())
+			if err != nil {
+				return nil, err
+			}
+			packed = append(packed, elem...)
+		}
+		return packed, nil
+	default:
+		return nil, fmt.Errorf("%s is not supported in packed mode", t.String())
+	}
+}
+
+func toBigInt(v reflect.Value) (*big.Int, error) {
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return big.NewInt(v.Int()), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return new(big.Int).SetUint64(v.Uint()), nil
+	case reflect.Ptr:
+		if n, ok := v.Interface().(*big.Int); ok && n != nil {
+			return n, nil
+		}
+	}
+	return nil, fmt.Errorf("%s is not an integer", v.Type())
+}
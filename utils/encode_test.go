@@ -0,0 +1,143 @@
+package utils
+
+import (
+	"bytes"
+	"io/ioutil"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/vm/runtime"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// the examples of abi.encodePacked in the solidity docs
+func TestEncodePacked(t *testing.T) {
+	packed, err := EncodePacked([]string{"int16", "bytes1", "uint16", "string"}, "-1", "B", "3", "Hello, world!")
+	require.Nil(t, err)
+	assert.Equal(t, "ffff42000348656c6c6f2c20776f726c6421", common.Bytes2Hex(packed))
+
+	// elements of arrays are padded, dynamic values are not
+	packed, err = EncodePacked([]string{"uint8[]", "bytes", "bool", "address"},
+		[]string{"1", "2"}, "0x1234", "true", "0x47bd692d7728dee508a2791701d54597cc1b8100")
+	require.Nil(t, err)
+	assert.Equal(t, strings.Repeat("0", 63)+"1"+strings.Repeat("0", 63)+"2"+"1234"+"01"+
+		"47bd692d7728dee508a2791701d54597cc1b8100", common.Bytes2Hex(packed))
+
+	// go values are taken as they are
+	packed, err = EncodePacked([]string{"uint24", "int8", "bytes32"}, big.NewInt(0x010203), int8(-2), common.Hash{0xff})
+	require.Nil(t, err)
+	assert.Equal(t, "010203"+"fe"+"ff"+strings.Repeat("0", 62), common.Bytes2Hex(packed))
+
+	_, err = EncodePacked([]string{"(uint256,string)"}, []string{"1", "a"})
+	require.NotNil(t, err)
+	_, err = EncodePacked([]string{"string[]"}, []string{"a"})
+	require.NotNil(t, err)
+	_, err = EncodePacked([]string{"uint256"})
+	require.NotNil(t, err)
+}
+
+// the examples of the abi specification
+func TestEncode(t *testing.T) {
+	data, err := Encode([]string{"uint32", "bool"}, "69", "true")
+	require.Nil(t, err)
+	assert.Equal(t, word("45")+word("1"), common.Bytes2Hex(data))
+
+	data, err = Encode([]string{"bytes", "bool", "uint256[]"}, "0x64617665", "true", []string{"1", "2", "3"})
+	require.Nil(t, err)
+	assert.Equal(t, word("60")+word("1")+word("a0")+word("4")+"64617665"+strings.Repeat("0", 56)+
+		word("3")+word("1")+word("2")+word("3"), common.Bytes2Hex(data))
+
+	// a dynamic tuple is encoded as its fields behind an offset
+	tuple, err := Encode([]string{"(uint256,(bool,string))"}, []interface{}{"1", []string{"true", "a"}})
+	require.Nil(t, err)
+	fields, err := Encode([]string{"uint256", "(bool,string)"}, "1", []string{"true", "a"})
+	require.Nil(t, err)
+	assert.Equal(t, append(common.FromHex(word("20")), fields...), tuple)
+
+	_, err = Encode([]string{"(uint256,string)"}, []string{"1"})
+	require.NotNil(t, err)
+	_, err = Encode([]string{"(uint256,string"}, []string{"1", "a"})
+	require.NotNil(t, err)
+	_, err = Encode([]string{"uint"}, "1")
+	require.NotNil(t, err)
+}
+
+func TestKeccak256(t *testing.T) {
+	hash, err := Keccak256Packed([]string{"string"}, "")
+	require.Nil(t, err)
+	assert.Equal(t, crypto.Keccak256Hash(nil), hash)
+
+	// Broker's computeHash: keccak256(abi.encodePacked(packed, args[i])) over the args
+	args := [][]byte{[]byte("interchain"), {0x1, 0x2}}
+	hash, err = Keccak256Packed([]string{"bytes", "bytes"}, args[0], args[1])
+	require.Nil(t, err)
+	assert.Equal(t, crypto.Keccak256Hash(bytes.Join(args, nil)), hash)
+
+	hash, err = Keccak256Encode([]string{"uint256"}, "1")
+	require.Nil(t, err)
+	assert.Equal(t, crypto.Keccak256Hash(common.FromHex(word("1"))), hash)
+}
+
+// the values returned by testdata/data.bin are abi.encode of its return types
+func TestEncodeContractOutput(t *testing.T) {
+	// the contract exceeds the code size limit of EIP-170, so it runs in an EVM without the limit
+	from := common.HexToAddress("0x20f7fac801c5fc3f7e20cfbadaa1cdb33d818fa3")
+	statedb, err := state.New(common.Hash{}, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
+	require.Nil(t, err)
+	cfg := &runtime.Config{
+		ChainConfig: &params.ChainConfig{ChainID: big.NewInt(1), HomesteadBlock: new(big.Int), ByzantiumBlock: new(big.Int),
+			ConstantinopleBlock: new(big.Int), PetersburgBlock: new(big.Int), IstanbulBlock: new(big.Int)},
+		Origin:   from,
+		GasLimit: 30000000,
+		State:    statedb,
+	}
+
+	contractAbi, err := LoadAbi("../testdata/data.abi")
+	require.Nil(t, err)
+	code, err := ioutil.ReadFile("../testdata/data.bin")
+	require.Nil(t, err)
+	_, address, _, err := runtime.Create(common.FromHex(string(code)), cfg)
+	require.Nil(t, err)
+
+	call := func(method string, args ...interface{}) []byte {
+		input, err := contractAbi.Pack(method, mustConvert(t, contractAbi, method, args...)...)
+		require.Nil(t, err)
+		output, _, err := runtime.Call(address, input, cfg)
+		require.Nil(t, err)
+		return output
+	}
+	sign := []string{"", "0", "", ""}
+	call("registerOrg", "123", "test-123", "bitxhub", sign)
+	user := "0x47bd692d7728dee508a2791701d54597cc1b8100"
+	call("registerUser", []string{user, "123", "1000", "extra"}, sign)
+
+	expected, err := Encode([]string{"(uint256,uint256,string)"}, []string{"123", "1000", "extra"})
+	require.Nil(t, err)
+	assert.Equal(t, expected, call("getUser", user))
+
+	expected, err = Encode([]string{"(address,string[],string)"}, []interface{}{from.Hex(), []string{"test-123"}, "bitxhub"})
+	require.Nil(t, err)
+	assert.Equal(t, expected, call("getOrg", "123"))
+}
+
+func mustConvert(t *testing.T, contractAbi abi.ABI, method string, args ...interface{}) []interface{} {
+	var converted []interface{}
+	for i, input := range contractAbi.Methods[method].Inputs {
+		arg, err := convert(input.Type, args[i])
+		require.Nil(t, err)
+		converted = append(converted, arg)
+	}
+	return converted
+}
+
+func word(hex string) string {
+	return strings.Repeat("0", 64-len(hex)) + hex
+}
@@ -0,0 +1,153 @@
+package utils
+
+import (
+	"crypto/ecdsa"
+	"crypto/hmac"
+	"crypto/sha512"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/meshplus/go-eth-client/signer"
+	"github.com/tyler-smith/go-bip39"
+)
+
+// DefaultBasePath is the BIP-44 path of ethereum accounts, the index of the account is appended to it
+const DefaultBasePath = "m/44'/60'/0'/0"
+
+// NewMnemonic generates a BIP-39 mnemonic from bits of entropy, 128 bits give 12 words and 256 bits give 24 words
+func NewMnemonic(bits int) (string, error) {
+	entropy, err := bip39.NewEntropy(bits)
+	if err != nil {
+		return "", err
+	}
+	return bip39.NewMnemonic(entropy)
+}
+
+// ValidateMnemonic checks the words and the checksum of mnemonic
+func ValidateMnemonic(mnemonic string) error {
+	if _, err := bip39.EntropyFromMnemonic(mnemonic); err != nil {
+		return fmt.Errorf("invalid mnemonic: %w", err)
+	}
+	return nil
+}
+
+// HDWallet derives deterministic accounts from a mnemonic following BIP-32 and BIP-44
+type HDWallet struct {
+	seed []byte
+}
+
+// NewHDWallet creates the wallet of mnemonic, passphrase is the optional BIP-39 password
+func NewHDWallet(mnemonic, passphrase string) (*HDWallet, error) {
+	if err := ValidateMnemonic(mnemonic); err != nil {
+		return nil, err
+	}
+	return &HDWallet{seed: bip39.NewSeed(mnemonic, passphrase)}, nil
+}
+
+// Derive returns the private key at path, such as m/44'/60'/0'/0/1
+func (w *HDWallet) Derive(path string) (*ecdsa.PrivateKey, error) {
+	derivationPath, err := accounts.ParseDerivationPath(path)
+	if err != nil {
+		return nil, err
+	}
+	return deriveKey(w.seed, derivationPath)
+}
+
+// Account returns the private key of the account index under DefaultBasePath
+func (w *HDWallet) Account(index uint32) (*ecdsa.PrivateKey, error) {
+	return w.Derive(fmt.Sprintf("%s/%d", DefaultBasePath, index))
+}
+
+// Addresses returns the addresses of the first n accounts under DefaultBasePath
+func (w *HDWallet) Addresses(n int) ([]common.Address, error) {
+	addresses := make([]common.Address, 0, n)
+	for i := 0; i < n; i++ {
+		key, err := w.Account(uint32(i))
+		if err != nil {
+			return nil, err
+		}
+		addresses = append(addresses, crypto.PubkeyToAddress(key.PublicKey))
+	}
+	return addresses, nil
+}
+
+// Signer returns a signer of the account at path for the write methods of the client
+func (w *HDWallet) Signer(path string) (*signer.KeySigner, error) {
+	key, err := w.Derive(path)
+	if err != nil {
+		return nil, err
+	}
+	return signer.NewKeySigner(key), nil
+}
+
+// ExportKeystore stores the key at path as a keystore file encrypted with params in dir, named as geth
+// names them, and returns the path of the file
+func (w *HDWallet) ExportKeystore(path, dir, password string, params ScryptParams) (string, error) {
+	key, err := w.Derive(path)
+	if err != nil {
+		return "", err
+	}
+	address := crypto.PubkeyToAddress(key.PublicKey)
+	keyJson, err := ExportKeystore(key, password, params)
+	if err != nil {
+		return "", fmt.Errorf("export %s: %w", path, err)
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", fmt.Errorf("export %s: %w", path, err)
+	}
+	file := filepath.Join(dir, fmt.Sprintf("UTC--%s--%s",
+		time.Now().UTC().Format("2006-01-02T15-04-05.000000000Z"), hex.EncodeToString(address[:])))
+	if err := ioutil.WriteFile(file, keyJson, 0600); err != nil {
+		return "", fmt.Errorf("export %s: %w", path, err)
+	}
+	return file, nil
+}
+
+// deriveKey derives the private key at path from seed with BIP-32 private parent to private child derivation
+func deriveKey(seed []byte, path accounts.DerivationPath) (*ecdsa.PrivateKey, error) {
+	mac := hmac.New(sha512.New, []byte("Bitcoin seed"))
+	mac.Write(seed)
+	sum := mac.Sum(nil)
+	key, chainCode := sum[:32], sum[32:]
+
+	n := crypto.S256().Params().N
+	for _, index := range path {
+		var data []byte
+		if index >= 0x80000000 {
+			data = append([]byte{0}, key...)
+		} else {
+			parent, err := crypto.ToECDSA(key)
+			if err != nil {
+				return nil, err
+			}
+			data = crypto.CompressPubkey(&parent.PublicKey)
+		}
+		data = append(data, make([]byte, 4)...)
+		binary.BigEndian.PutUint32(data[len(data)-4:], index)
+
+		mac := hmac.New(sha512.New, chainCode)
+		mac.Write(data)
+		sum := mac.Sum(nil)
+		il := new(big.Int).SetBytes(sum[:32])
+		if il.Cmp(n) >= 0 {
+			return nil, fmt.Errorf("invalid child key at index %d", index)
+		}
+		child := il.Add(il, new(big.Int).SetBytes(key))
+		child.Mod(child, n)
+		if child.Sign() == 0 {
+			return nil, fmt.Errorf("invalid child key at index %d", index)
+		}
+		key, chainCode = math.PaddedBigBytes(child, 32), sum[32:]
+	}
+	return crypto.ToECDSA(key)
+}
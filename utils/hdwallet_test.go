@@ -0,0 +1,70 @@
+package utils
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testMnemonic = "test test test test test test test test test test test junk"
+
+func TestHDWallet(t *testing.T) {
+	wallet, err := NewHDWallet(testMnemonic, "")
+	require.Nil(t, err)
+	addresses, err := wallet.Addresses(3)
+	require.Nil(t, err)
+	assert.Equal(t, []common.Address{
+		common.HexToAddress("0xf39Fd6e51aad88F6F4ce6aB8827279cffFb92266"),
+		common.HexToAddress("0x70997970C51812dc3A010C7d01b50e0d17dc79C8"),
+		common.HexToAddress("0x3C44CdDdB6a900fa2b585dd299e03d12FA4293BC"),
+	}, addresses)
+
+	key, err := wallet.Derive("m/44'/60'/0'/0/0")
+	require.Nil(t, err)
+	assert.Equal(t, "ac0974bec39a17e36ba4a6b4d238ff944bacb478cbed5efcae784d7bf4f2ff80", common.Bytes2Hex(crypto.FromECDSA(key)))
+	s, err := wallet.Signer("m/44'/60'/0'/0/1")
+	require.Nil(t, err)
+	assert.Equal(t, addresses[1], s.Address())
+
+	// the passphrase gives other accounts
+	other, err := NewHDWallet(testMnemonic, "bitxhub")
+	require.Nil(t, err)
+	key, err = other.Account(0)
+	require.Nil(t, err)
+	assert.NotEqual(t, addresses[0], crypto.PubkeyToAddress(key.PublicKey))
+
+	_, err = wallet.Derive("m/44'/60'/0'/x")
+	require.NotNil(t, err)
+}
+
+func TestMnemonic(t *testing.T) {
+	mnemonic, err := NewMnemonic(128)
+	require.Nil(t, err)
+	assert.Equal(t, 12, len(strings.Fields(mnemonic)))
+	require.Nil(t, ValidateMnemonic(mnemonic))
+	mnemonic, err = NewMnemonic(256)
+	require.Nil(t, err)
+	assert.Equal(t, 24, len(strings.Fields(mnemonic)))
+
+	require.NotNil(t, ValidateMnemonic("test test test test test test test test test test test test"))
+	require.NotNil(t, ValidateMnemonic("bitxhub"))
+	_, err = NewHDWallet("bitxhub", "")
+	require.NotNil(t, err)
+}
+
+func TestHDWalletExportKeystore(t *testing.T) {
+	wallet, err := NewHDWallet(testMnemonic, "")
+	require.Nil(t, err)
+	path, err := wallet.ExportKeystore("m/44'/60'/0'/0/2", t.TempDir(), "bitxhub", LightScrypt)
+	require.Nil(t, err)
+	assert.True(t, strings.HasPrefix(filepath.Base(path), "UTC--"))
+	assert.True(t, strings.HasSuffix(path, "--3c44cdddb6a900fa2b585dd299e03d12fa4293bc"))
+	_, addr, err := KeystoreToPrivateKey(path, "bitxhub")
+	require.Nil(t, err)
+	assert.Equal(t, "0x3C44CdDdB6a900fa2b585dd299e03d12FA4293BC", addr)
+}
@@ -0,0 +1,205 @@
+package utils
+
+import (
+	"crypto/ecdsa"
+	"encoding/asn1"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/google/uuid"
+)
+
+// ScryptParams is the strength of the key derivation of keystore files
+type ScryptParams struct {
+	N int
+	P int
+}
+
+var (
+	// LightScrypt is fast and uses 4MB of memory, it is fit for tests and dev chains
+	LightScrypt = ScryptParams{N: keystore.LightScryptN, P: keystore.LightScryptP}
+	// StandardScrypt is the strength of geth and MetaMask, it takes about a second and uses 256MB of memory
+	StandardScrypt = ScryptParams{N: keystore.StandardScryptN, P: keystore.StandardScryptP}
+)
+
+var (
+	oidPublicKeyECDSA = asn1.ObjectIdentifier{1, 2, 840, 10045, 2, 1}
+	oidSecp256k1      = asn1.ObjectIdentifier{1, 3, 132, 0, 10}
+)
+
+// pkcs8 is the PrivateKeyInfo of RFC 5208
+type pkcs8 struct {
+	Version    int
+	Algo       pkcs8Algorithm
+	PrivateKey []byte
+}
+
+type pkcs8Algorithm struct {
+	Algorithm  asn1.ObjectIdentifier
+	Parameters asn1.ObjectIdentifier
+}
+
+// ecPrivateKey is the ECPrivateKey of RFC 5915
+type ecPrivateKey struct {
+	Version       int
+	PrivateKey    []byte
+	NamedCurveOID asn1.ObjectIdentifier `asn1:"optional,explicit,tag:0"`
+	PublicKey     asn1.BitString        `asn1:"optional,explicit,tag:1"`
+}
+
+// ExportHex returns the key as 64 hex characters without 0x prefix, as MetaMask does
+func ExportHex(key *ecdsa.PrivateKey) string {
+	return hex.EncodeToString(crypto.FromECDSA(key))
+}
+
+// ImportHex parses a hex key with or without 0x prefix
+func ImportHex(s string) (*ecdsa.PrivateKey, error) {
+	s = strings.TrimPrefix(strings.TrimSpace(s), "0x")
+	key, err := crypto.HexToECDSA(s)
+	if err != nil {
+		return nil, fmt.Errorf("import hex key: %w", err)
+	}
+	return key, nil
+}
+
+// ExportKeystore encrypts key to keystore v3 json with password and the scrypt strength params
+func ExportKeystore(key *ecdsa.PrivateKey, password string, params ScryptParams) ([]byte, error) {
+	id, err := uuid.NewRandom()
+	if err != nil {
+		return nil, err
+	}
+	address := crypto.PubkeyToAddress(key.PublicKey)
+	keyJson, err := keystore.EncryptKey(&keystore.Key{Id: id, Address: address, PrivateKey: key}, password, params.N, params.P)
+	if err != nil {
+		return nil, fmt.Errorf("encrypt key: %w", err)
+	}
+	return keyJson, nil
+}
+
+// ImportKeystore decrypts keystore v3 json, the key must match the address of the file
+func ImportKeystore(keyJson []byte, password string) (*ecdsa.PrivateKey, error) {
+	key, err := keystore.DecryptKey(keyJson, password)
+	if err != nil {
+		return nil, fmt.Errorf("decrypt keystore: %w", err)
+	}
+	var file struct {
+		Address string `json:"address"`
+	}
+	if err := json.Unmarshal(keyJson, &file); err != nil {
+		return nil, fmt.Errorf("decode keystore: %w", err)
+	}
+	if file.Address != "" {
+		if !common.IsHexAddress(file.Address) {
+			return nil, fmt.Errorf("invalid keystore address %s", file.Address)
+		}
+		if err := checkAddress(key.PrivateKey, common.HexToAddress(file.Address)); err != nil {
+			return nil, err
+		}
+	}
+	return key.PrivateKey, nil
+}
+
+// ExportPEM encodes key as a PKCS#8 "PRIVATE KEY" pem block, readable by openssl
+func ExportPEM(key *ecdsa.PrivateKey) ([]byte, error) {
+	ecKey, err := asn1.Marshal(ecPrivateKey{
+		Version:    1,
+		PrivateKey: math.PaddedBigBytes(key.D, 32),
+		PublicKey:  asn1.BitString{Bytes: crypto.FromECDSAPub(&key.PublicKey), BitLength: 65 * 8},
+	})
+	if err != nil {
+		return nil, err
+	}
+	der, err := asn1.Marshal(pkcs8{
+		Algo:       pkcs8Algorithm{Algorithm: oidPublicKeyECDSA, Parameters: oidSecp256k1},
+		PrivateKey: ecKey,
+	})
+	if err != nil {
+		return nil, err
+	}
+	data := pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})
+	decoded, err := ImportPEM(data)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkAddress(decoded, crypto.PubkeyToAddress(key.PublicKey)); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// ImportPEM decodes a PKCS#8 "PRIVATE KEY" or a SEC 1 "EC PRIVATE KEY" pem block of a secp256k1 key
+func ImportPEM(data []byte) (*ecdsa.PrivateKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("import pem: no pem block")
+	}
+	der := block.Bytes
+	switch block.Type {
+	case "PRIVATE KEY":
+		var info pkcs8
+		if _, err := asn1.Unmarshal(der, &info); err != nil {
+			return nil, fmt.Errorf("import pem: %w", err)
+		}
+		if !info.Algo.Algorithm.Equal(oidPublicKeyECDSA) || !info.Algo.Parameters.Equal(oidSecp256k1) {
+			return nil, fmt.Errorf("import pem: not a secp256k1 key")
+		}
+		der = info.PrivateKey
+	case "EC PRIVATE KEY":
+	default:
+		return nil, fmt.Errorf("import pem: unsupported block type %s", block.Type)
+	}
+
+	var ecKey ecPrivateKey
+	if _, err := asn1.Unmarshal(der, &ecKey); err != nil {
+		return nil, fmt.Errorf("import pem: %w", err)
+	}
+	if ecKey.NamedCurveOID != nil && !ecKey.NamedCurveOID.Equal(oidSecp256k1) {
+		return nil, fmt.Errorf("import pem: not a secp256k1 key")
+	}
+	key, err := crypto.ToECDSA(math.PaddedBigBytes(new(big.Int).SetBytes(ecKey.PrivateKey), 32))
+	if err != nil {
+		return nil, fmt.Errorf("import pem: %w", err)
+	}
+	if len(ecKey.PublicKey.Bytes) != 0 {
+		pub, err := crypto.UnmarshalPubkey(ecKey.PublicKey.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("import pem: %w", err)
+		}
+		if err := checkAddress(key, crypto.PubkeyToAddress(*pub)); err != nil {
+			return nil, err
+		}
+	}
+	return key, nil
+}
+
+// ImportMnemonic derives the key at path, such as m/44'/60'/0'/0/0, from mnemonic
+func ImportMnemonic(mnemonic, passphrase, path string) (*ecdsa.PrivateKey, error) {
+	wallet, err := NewHDWallet(mnemonic, passphrase)
+	if err != nil {
+		return nil, err
+	}
+	return wallet.Derive(path)
+}
+
+// VerifyAddress checks that key belongs to address, such as the address noted next to an imported key
+func VerifyAddress(key *ecdsa.PrivateKey, address string) error {
+	if !common.IsHexAddress(address) {
+		return fmt.Errorf("invalid address %s", address)
+	}
+	return checkAddress(key, common.HexToAddress(address))
+}
+
+func checkAddress(key *ecdsa.PrivateKey, address common.Address) error {
+	if got := crypto.PubkeyToAddress(key.PublicKey); got != address {
+		return fmt.Errorf("key belongs to %s, expected %s", got, address)
+	}
+	return nil
+}
@@ -0,0 +1,275 @@
+package utils
+
+import (
+	"crypto/ecdsa"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	ethsigner "github.com/ethereum/go-ethereum/signer/core"
+	"github.com/meshplus/go-eth-client/signer"
+)
+
+// PasswordFunc returns the password of the account at address
+type PasswordFunc func(address common.Address) (string, error)
+
+// Password returns the fixed password
+func Password(password string) PasswordFunc {
+	return func(common.Address) (string, error) {
+		return password, nil
+	}
+}
+
+// PasswordFile reads the password from the first line of the file at path
+func PasswordFile(path string) PasswordFunc {
+	return func(common.Address) (string, error) {
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return "", fmt.Errorf("read password file: %w", err)
+		}
+		return strings.TrimRight(strings.SplitN(string(data), "\n", 2)[0], "\r"), nil
+	}
+}
+
+// PasswordEnv reads the password from the environment variable name
+func PasswordEnv(name string) PasswordFunc {
+	return func(common.Address) (string, error) {
+		password, ok := os.LookupEnv(name)
+		if !ok {
+			return "", fmt.Errorf("environment variable %s is not set", name)
+		}
+		return password, nil
+	}
+}
+
+// ErrKeystoreClosed is returned by a KeystoreManager after Close
+var ErrKeystoreClosed = errors.New("keystore manager is closed")
+
+// KeystoreManager manages the keystore files in a directory. The directory is watched for changes
+// until Close.
+type KeystoreManager struct {
+	ks *keystore.KeyStore // 关闭后为nil
+	mu sync.RWMutex
+}
+
+// NewKeystoreManager opens the keystore directory dir, it is created if it doesn't exist.
+// New keys are encrypted with the scrypt parameters n and p, such as keystore.StandardScryptN and keystore.StandardScryptP.
+func NewKeystoreManager(dir string, n, p int) (*KeystoreManager, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, fmt.Errorf("create keystore dir: %w", err)
+	}
+	return &KeystoreManager{ks: keystore.NewKeyStore(dir, n, p)}, nil
+}
+
+// Close locks the unlocked accounts and releases the keystore, its directory watcher stops once the keystore
+// is garbage collected, which needs the signers returned by Signer to be released as well
+func (m *KeystoreManager) Close() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.ks == nil {
+		return
+	}
+	for _, account := range m.ks.Accounts() {
+		_ = m.ks.Lock(account.Address)
+	}
+	m.ks = nil
+}
+
+// keystore returns the keystore, or ErrKeystoreClosed after Close
+func (m *KeystoreManager) keystore() (*keystore.KeyStore, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if m.ks == nil {
+		return nil, ErrKeystoreClosed
+	}
+	return m.ks, nil
+}
+
+// List returns the addresses of the accounts in the directory, none after Close
+func (m *KeystoreManager) List() []common.Address {
+	ks, err := m.keystore()
+	if err != nil {
+		return nil
+	}
+	accounts := ks.Accounts()
+	addresses := make([]common.Address, 0, len(accounts))
+	for _, account := range accounts {
+		addresses = append(addresses, account.Address)
+	}
+	return addresses
+}
+
+// Path returns the path of the keystore file of address
+func (m *KeystoreManager) Path(address common.Address) (string, error) {
+	_, account, err := m.find(address)
+	if err != nil {
+		return "", err
+	}
+	return account.URL.Path, nil
+}
+
+// NewAccount generates a key and stores it encrypted with password
+func (m *KeystoreManager) NewAccount(password string) (common.Address, error) {
+	ks, err := m.keystore()
+	if err != nil {
+		return common.Address{}, err
+	}
+	account, err := ks.NewAccount(password)
+	if err != nil {
+		return common.Address{}, fmt.Errorf("new account: %w", err)
+	}
+	return account.Address, nil
+}
+
+// Import stores the keystore json keyJson encrypted with password, the stored file is encrypted with newPassword
+func (m *KeystoreManager) Import(keyJson []byte, password, newPassword string) (common.Address, error) {
+	ks, err := m.keystore()
+	if err != nil {
+		return common.Address{}, err
+	}
+	account, err := ks.Import(keyJson, password, newPassword)
+	if err != nil {
+		return common.Address{}, fmt.Errorf("import keystore: %w", err)
+	}
+	return account.Address, nil
+}
+
+// ImportKey stores key encrypted with password
+func (m *KeystoreManager) ImportKey(key *ecdsa.PrivateKey, password string) (common.Address, error) {
+	ks, err := m.keystore()
+	if err != nil {
+		return common.Address{}, err
+	}
+	account, err := ks.ImportECDSA(key, password)
+	if err != nil {
+		return common.Address{}, fmt.Errorf("import key: %w", err)
+	}
+	return account.Address, nil
+}
+
+// Export returns the keystore json of address encrypted with newPassword
+func (m *KeystoreManager) Export(address common.Address, password, newPassword string) ([]byte, error) {
+	ks, account, err := m.find(address)
+	if err != nil {
+		return nil, err
+	}
+	keyJson, err := ks.Export(account, password, newPassword)
+	if err != nil {
+		return nil, fmt.Errorf("export %s: %w", address, err)
+	}
+	return keyJson, nil
+}
+
+// Unlock decrypts the key of address with the password from password. The key is locked
+// again after timeout, or stays unlocked until Lock if timeout is 0.
+func (m *KeystoreManager) Unlock(address common.Address, password PasswordFunc, timeout time.Duration) error {
+	ks, account, err := m.find(address)
+	if err != nil {
+		return err
+	}
+	passphrase, err := password(address)
+	if err != nil {
+		return fmt.Errorf("password of %s: %w", address, err)
+	}
+	if err := ks.TimedUnlock(account, passphrase, timeout); err != nil {
+		return fmt.Errorf("unlock %s: %w", address, err)
+	}
+	return nil
+}
+
+// Lock removes the decrypted key of address from memory
+func (m *KeystoreManager) Lock(address common.Address) error {
+	ks, err := m.keystore()
+	if err != nil {
+		return err
+	}
+	return ks.Lock(address)
+}
+
+// ChangePassword encrypts the key of address with newPassword, the file is replaced atomically
+func (m *KeystoreManager) ChangePassword(address common.Address, password, newPassword string) error {
+	ks, account, err := m.find(address)
+	if err != nil {
+		return err
+	}
+	if err := ks.Update(account, password, newPassword); err != nil {
+		return fmt.Errorf("change password of %s: %w", address, err)
+	}
+	return nil
+}
+
+// Delete removes the keystore file of address, the password is checked before so that
+// a key can't be lost by mistake
+func (m *KeystoreManager) Delete(address common.Address, password string) error {
+	ks, account, err := m.find(address)
+	if err != nil {
+		return err
+	}
+	if err := ks.Delete(account, password); err != nil {
+		return fmt.Errorf("delete %s: %w", address, err)
+	}
+	return nil
+}
+
+// Signer returns a signer of address, it signs only while the account is unlocked
+func (m *KeystoreManager) Signer(address common.Address) (signer.Signer, error) {
+	ks, account, err := m.find(address)
+	if err != nil {
+		return nil, err
+	}
+	return &keystoreSigner{ks: ks, account: account}, nil
+}
+
+// find returns the keystore along with the account of address
+func (m *KeystoreManager) find(address common.Address) (*keystore.KeyStore, accounts.Account, error) {
+	ks, err := m.keystore()
+	if err != nil {
+		return nil, accounts.Account{}, err
+	}
+	account, err := ks.Find(accounts.Account{Address: address})
+	if err != nil {
+		return nil, accounts.Account{}, fmt.Errorf("find %s: %w", address, err)
+	}
+	return ks, account, nil
+}
+
+// keystoreSigner signs with an unlocked account of a keystore, it fails with keystore.ErrLocked otherwise
+type keystoreSigner struct {
+	ks      *keystore.KeyStore
+	account accounts.Account
+}
+
+func (s *keystoreSigner) Address() common.Address {
+	return s.account.Address
+}
+
+func (s *keystoreSigner) SignTx(tx *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
+	return s.ks.SignTx(s.account, tx, chainID)
+}
+
+func (s *keystoreSigner) SignHash(hash []byte) ([]byte, error) {
+	return s.ks.SignHash(s.account, hash)
+}
+
+func (s *keystoreSigner) SignTypedData(typedData ethsigner.TypedData) ([]byte, error) {
+	hash, err := signer.TypedDataHash(typedData)
+	if err != nil {
+		return nil, err
+	}
+	sig, err := s.ks.SignHash(s.account, hash)
+	if err != nil {
+		return nil, err
+	}
+	sig[crypto.RecoveryIDOffset] += 27
+	return sig, nil
+}
@@ -0,0 +1,144 @@
+package utils
+
+import (
+	"errors"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newKeystoreManager(t *testing.T) *KeystoreManager {
+	m, err := NewKeystoreManager(filepath.Join(t.TempDir(), "keystore"), keystore.LightScryptN, keystore.LightScryptP)
+	require.Nil(t, err)
+	return m
+}
+
+func TestKeystoreManagerImportExport(t *testing.T) {
+	m := newKeystoreManager(t)
+	key, err := crypto.GenerateKey()
+	require.Nil(t, err)
+	address, err := m.ImportKey(key, "bitxhub")
+	require.Nil(t, err)
+	assert.Equal(t, crypto.PubkeyToAddress(key.PublicKey), address)
+	_, err = m.ImportKey(key, "bitxhub")
+	require.True(t, errors.Is(err, keystore.ErrAccountAlreadyExists))
+
+	other, err := m.NewAccount("other")
+	require.Nil(t, err)
+	assert.ElementsMatch(t, []common.Address{address, other}, m.List())
+
+	keyJson, err := m.Export(address, "bitxhub", "exported")
+	require.Nil(t, err)
+	_, err = m.Export(address, "wrong", "exported")
+	require.True(t, errors.Is(err, keystore.ErrDecrypt))
+
+	// the exported file moves to another directory with its new password
+	m2 := newKeystoreManager(t)
+	imported, err := m2.Import(keyJson, "exported", "imported")
+	require.Nil(t, err)
+	assert.Equal(t, address, imported)
+	path, err := m2.Path(imported)
+	require.Nil(t, err)
+	_, addr, err := KeystoreToPrivateKey(path, "imported")
+	require.Nil(t, err)
+	assert.Equal(t, address.String(), addr)
+
+	_, _, err = KeystoreToPrivateKey(filepath.Join(t.TempDir(), "missing"), "imported")
+	require.NotNil(t, err)
+}
+
+func TestKeystoreManagerUnlock(t *testing.T) {
+	m := newKeystoreManager(t)
+	address, err := m.NewAccount("bitxhub")
+	require.Nil(t, err)
+	s, err := m.Signer(address)
+	require.Nil(t, err)
+	tx := types.NewTransaction(0, common.Address{0x1}, big.NewInt(1), 21000, big.NewInt(1), nil)
+
+	_, err = s.SignTx(tx, big.NewInt(1356))
+	require.Equal(t, keystore.ErrLocked, err)
+
+	dir := t.TempDir()
+	passwordFile := filepath.Join(dir, "password")
+	require.Nil(t, ioutil.WriteFile(passwordFile, []byte("bitxhub\n"), 0600))
+	require.Nil(t, m.Unlock(address, PasswordFile(passwordFile), 0))
+	signed, err := s.SignTx(tx, big.NewInt(1356))
+	require.Nil(t, err)
+	sender, err := types.Sender(types.LatestSignerForChainID(big.NewInt(1356)), signed)
+	require.Nil(t, err)
+	assert.Equal(t, address, sender)
+	require.Nil(t, m.Lock(address))
+	_, err = s.SignTx(tx, big.NewInt(1356))
+	require.Equal(t, keystore.ErrLocked, err)
+
+	// the key is locked again after the timeout
+	require.Nil(t, os.Setenv("KEYSTORE_TEST_PASSWORD", "bitxhub"))
+	defer os.Unsetenv("KEYSTORE_TEST_PASSWORD")
+	require.Nil(t, m.Unlock(address, PasswordEnv("KEYSTORE_TEST_PASSWORD"), 200*time.Millisecond))
+	_, err = s.SignHash(crypto.Keccak256(nil))
+	require.Nil(t, err)
+	time.Sleep(400 * time.Millisecond)
+	_, err = s.SignHash(crypto.Keccak256(nil))
+	require.Equal(t, keystore.ErrLocked, err)
+
+	var asked common.Address
+	require.NotNil(t, m.Unlock(address, func(addr common.Address) (string, error) {
+		asked = addr
+		return "wrong", nil
+	}, 0))
+	assert.Equal(t, address, asked)
+	require.NotNil(t, m.Unlock(address, PasswordEnv("KEYSTORE_TEST_MISSING"), 0))
+}
+
+func TestKeystoreManagerChangePasswordAndDelete(t *testing.T) {
+	m := newKeystoreManager(t)
+	address, err := m.NewAccount("bitxhub")
+	require.Nil(t, err)
+
+	require.NotNil(t, m.ChangePassword(address, "wrong", "new"))
+	require.Nil(t, m.ChangePassword(address, "bitxhub", "new"))
+	require.NotNil(t, m.Unlock(address, Password("bitxhub"), 0))
+	require.Nil(t, m.Unlock(address, Password("new"), 0))
+
+	path, err := m.Path(address)
+	require.Nil(t, err)
+	require.NotNil(t, m.Delete(address, "bitxhub"))
+	_, err = os.Stat(path)
+	require.Nil(t, err)
+	require.Nil(t, m.Delete(address, "new"))
+	_, err = os.Stat(path)
+	require.True(t, os.IsNotExist(err))
+	assert.Empty(t, m.List())
+	_, err = m.Signer(address)
+	require.True(t, errors.Is(err, keystore.ErrNoMatch))
+}
+
+func TestKeystoreManagerClose(t *testing.T) {
+	m := newKeystoreManager(t)
+	address, err := m.NewAccount("bitxhub")
+	require.Nil(t, err)
+	require.Nil(t, m.Unlock(address, Password("bitxhub"), 0))
+	s, err := m.Signer(address)
+	require.Nil(t, err)
+
+	m.Close()
+	m.Close()
+	assert.Empty(t, m.List())
+	_, err = m.NewAccount("bitxhub")
+	require.True(t, errors.Is(err, ErrKeystoreClosed))
+	_, err = m.Signer(address)
+	require.True(t, errors.Is(err, ErrKeystoreClosed))
+	// the account is locked on close
+	_, err = s.SignHash(crypto.Keccak256([]byte("bitxhub")))
+	require.True(t, errors.Is(err, keystore.ErrLocked))
+}
@@ -93,7 +93,7 @@ func convert(t abi.Type, input interface{}) (interface{}, error) {
 
 		// complete input with default "" (empty string)
 		for i := idx; i < t.Size; i++ {
-			fmtVal[idx] = ""
+			fmtVal[i] = ""
 		}
 		// build the array (not slice)
 		data := reflect.New(t.GetType()).Elem()
@@ -131,6 +131,22 @@ func convert(t abi.Type, input interface{}) (interface{}, error) {
 		}
 		return data.Interface(), nil
 
+	case abi.TupleTy:
+		// the fields of a tuple are given in order
+		reflectInput := reflect.ValueOf(input)
+		if reflectInput.Kind() != reflect.Slice || reflectInput.Len() != len(t.TupleElems) {
+			return nil, fmt.Errorf("%s takes %d fields", t.String(), len(t.TupleElems))
+		}
+		data := reflect.New(t.GetType()).Elem()
+		for idx, elemTy := range t.TupleElems {
+			elem, err := convert(*elemTy, reflectInput.Index(idx).Interface())
+			if err != nil {
+				return nil, err
+			}
+			data.Field(idx).Set(reflect.ValueOf(elem))
+		}
+		return data.Interface(), nil
+
 	case abi.FixedBytesTy:
 		if str, ok := input.(string); ok {
 			return newFixedBytes(t.Size, str), nil
@@ -151,6 +167,17 @@ func newElement(t abi.Type, val string) (interface{}, error) {
 	}
 	var UNIT = 64
 	var elem interface{}
+	if (t.T == abi.IntTy || t.T == abi.UintTy) && t.GetType() == reflect.TypeOf(&big.Int{}) {
+		// uint256, int24 and the other sizes without go types are big ints
+		if val == "" {
+			return big.NewInt(0), nil
+		}
+		num, ok := big.NewInt(0).SetString(val, 10)
+		if !ok {
+			return nil, fmt.Errorf("set big int failed")
+		}
+		return num, nil
+	}
 	switch t.String() {
 	case "uint8":
 		num, err := strconv.ParseUint(val, 10, UNIT)
@@ -176,18 +203,6 @@ func newElement(t abi.Type, val string) (interface{}, error) {
 			return nil, err
 		}
 		elem = num
-	case "uint128", "uint256", "int128", "int256":
-		var num *big.Int
-		var ok bool
-		if val == "" {
-			num = big.NewInt(0)
-		} else {
-			num, ok = big.NewInt(0).SetString(val, 10)
-			if !ok {
-				return nil, fmt.Errorf("set big int failed")
-			}
-		}
-		elem = num
 	case "int8":
 		num, err := strconv.ParseInt(val, 10, UNIT)
 		if err != nil {
@@ -0,0 +1,48 @@
+package utils
+
+import (
+	"math/big"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const decodeAbi = `[{"type":"function","name":"set","stateMutability":"nonpayable","outputs":[],"inputs":[
+	{"name":"small","type":"uint24"},
+	{"name":"signed","type":"int40"},
+	{"name":"fixed","type":"uint256[3]"},
+	{"name":"pair","type":"tuple","components":[{"name":"amount","type":"uint256"},{"name":"memo","type":"string"}]}
+]}]`
+
+func TestDecode(t *testing.T) {
+	contractAbi, err := abi.JSON(strings.NewReader(decodeAbi))
+	require.Nil(t, err)
+
+	// the missing elements of a fixed array are zero
+	args, err := Decode(&contractAbi, "set", "70000", "-549755813888", []string{"1", "2"}, []interface{}{"10", "memo"})
+	require.Nil(t, err)
+	require.Len(t, args, 4)
+	assert.Equal(t, big.NewInt(70000), args[0])
+	assert.Equal(t, big.NewInt(-549755813888), args[1])
+	assert.Equal(t, [3]*big.Int{big.NewInt(1), big.NewInt(2), big.NewInt(0)}, args[2])
+	pair := reflect.ValueOf(args[3])
+	assert.Equal(t, big.NewInt(10), pair.Field(0).Interface())
+	assert.Equal(t, "memo", pair.Field(1).Interface())
+	_, err = contractAbi.Pack("set", args...)
+	require.Nil(t, err)
+
+	// a single value fills the first element
+	args, err = Decode(&contractAbi, "set", "", "", "7", []string{"", ""})
+	require.Nil(t, err)
+	assert.Equal(t, big.NewInt(0), args[0])
+	assert.Equal(t, [3]*big.Int{big.NewInt(7), big.NewInt(0), big.NewInt(0)}, args[2])
+
+	_, err = Decode(&contractAbi, "set", "1", "1", "1", []string{"1"})
+	require.NotNil(t, err)
+	_, err = Decode(&contractAbi, "set", "x", "1", "1", []string{"1", "a"})
+	require.NotNil(t, err)
+}